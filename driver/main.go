@@ -2,13 +2,22 @@ package main
 
 import (
 	"flag"
+	"log"
 	"Go-Mini-Spark/pkg/driver"
 )
 
 func main() {
 	port := flag.String("port", "9000", "Port for the driver to listen on")
+	broadcastThreshold := flag.Int64("broadcast-threshold", driver.DefaultBroadcastJoinThreshold, "Estimated size in bytes under which a join side is broadcast (0 disables)")
+	pluginDir := flag.String("plugins", "", "Directory of function plugins (.so) for the functions the driver runs itself (Reduce, Aggregate, Top, SortBy)")
 	flag.Parse()
 
 	d := driver.NewDriver(*port)
+	d.BroadcastJoinThreshold = *broadcastThreshold
+	if *pluginDir != "" {
+		if err := d.LoadPlugins(*pluginDir); err != nil {
+			log.Fatalf("Error loading plugins from %s: %v", *pluginDir, err)
+		}
+	}
 	d.Start()
 }
@@ -0,0 +1,127 @@
+package driver
+
+import (
+	"Go-Mini-Spark/pkg/types"
+	"fmt"
+	"log"
+)
+
+// accumulator es el valor acumulado en el driver, seen son los elementos que
+// ya tiene un SetAccumulator
+type accumulator struct {
+	value types.AccumulatorValue
+	seen  map[string]bool
+}
+
+// droppedAccumulator es el nombre del acumulador con las filas descartadas por
+// el Filter que produjo el RDD rddID
+func droppedAccumulator(rddID int) string {
+	return fmt.Sprintf("filter.%d.dropped", rddID)
+}
+
+func (d *Driver) createAccumulator(name string, kind types.AccumulatorKind) error {
+	d.AccumulatorMutex.Lock()
+	defer d.AccumulatorMutex.Unlock()
+	return d.createAccumulatorLocked(name, kind)
+}
+
+func (d *Driver) createAccumulatorLocked(name string, kind types.AccumulatorKind) error {
+	if acc, exists := d.accumulators[name]; exists {
+		if acc.value.Kind != kind {
+			return fmt.Errorf("accumulator '%s' already exists with another kind", name)
+		}
+		return nil
+	}
+
+	d.accumulators[name] = &accumulator{
+		value: types.AccumulatorValue{Name: name, Kind: kind},
+		seen:  make(map[string]bool),
+	}
+	return nil
+}
+
+// mergeTaskAccumulators aplica los cambios que hizo cada transformación de la
+// task. Se llama una vez por task de un job que terminó: un reintento solo
+// ocurre si no llegó respuesta, así que sus cambios no se cuentan dos veces.
+// Una acción que vuelve a evaluar el RDD, o un RDD que aparece dos veces en un
+// Union, sí vuelve a aplicar los cambios de sus transformaciones.
+func (d *Driver) mergeTaskAccumulators(updates map[int]types.AccumulatorUpdates) {
+	for _, rddUpdates := range updates {
+		d.mergeAccumulators(rddUpdates)
+	}
+}
+
+// mergeAccumulators aplica updates. Los acumuladores que no existen se crean.
+func (d *Driver) mergeAccumulators(updates types.AccumulatorUpdates) {
+	if len(updates.Sums)+len(updates.Lists)+len(updates.Sets) == 0 {
+		return
+	}
+
+	d.AccumulatorMutex.Lock()
+	defer d.AccumulatorMutex.Unlock()
+
+	for name, n := range updates.Sums {
+		if d.accumulatorFor(name, types.SumAccumulator) {
+			d.accumulators[name].value.Sum += n
+		}
+	}
+	for name, values := range updates.Lists {
+		if d.accumulatorFor(name, types.ListAccumulator) {
+			acc := d.accumulators[name]
+			acc.value.Values = append(acc.value.Values, values...)
+		}
+	}
+	for name, values := range updates.Sets {
+		if !d.accumulatorFor(name, types.SetAccumulator) {
+			continue
+		}
+		acc := d.accumulators[name]
+		for _, value := range values {
+			id := fmt.Sprintf("%v", value)
+			if !acc.seen[id] {
+				acc.seen[id] = true
+				acc.value.Values = append(acc.value.Values, value)
+			}
+		}
+	}
+}
+
+// accumulatorFor crea el acumulador si hace falta y reporta si es del tipo kind
+func (d *Driver) accumulatorFor(name string, kind types.AccumulatorKind) bool {
+	if err := d.createAccumulatorLocked(name, kind); err != nil {
+		log.Printf("Ignoring accumulator update: %v\n", err)
+		return false
+	}
+	return true
+}
+
+// CreateAccumulator RPC method - declara un acumulador para que GetAccumulator
+// lo encuentre antes de que alguna task lo actualice
+func (d *Driver) CreateAccumulator(args types.AccumulatorArgs, reply *bool) error {
+	if args.Name == "" {
+		return fmt.Errorf("accumulator name cannot be empty")
+	}
+	if err := d.createAccumulator(args.Name, args.Kind); err != nil {
+		return err
+	}
+
+	*reply = true
+	return nil
+}
+
+// GetAccumulator RPC method - retorna el valor acumulado por las tasks que ya
+// terminaron
+func (d *Driver) GetAccumulator(name string, reply *types.AccumulatorValue) error {
+	d.AccumulatorMutex.Lock()
+	defer d.AccumulatorMutex.Unlock()
+
+	acc, exists := d.accumulators[name]
+	if !exists {
+		return fmt.Errorf("accumulator '%s' not found", name)
+	}
+
+	value := acc.value
+	value.Values = append([]interface{}{}, acc.value.Values...)
+	*reply = value
+	return nil
+}
@@ -0,0 +1,387 @@
+package driver
+
+import (
+	"Go-Mini-Spark/pkg/types"
+	"Go-Mini-Spark/pkg/utils"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+)
+
+// takeScaleFactor es cuánto crece el número de particiones evaluadas en cada
+// ronda de Take cuando las anteriores no alcanzaron n filas
+const takeScaleFactor = 4
+
+// defaultRelativeSD y defaultSketchK se usan cuando CountApproxDistinct y
+// ApproxQuantile no indican la precisión
+const defaultRelativeSD = 0.01
+const defaultSketchK = 200
+
+// Count RPC method - cada worker devuelve solo la cantidad de filas de su partición
+func (d *Driver) Count(id int, reply *int) error {
+	r, exists := d.RDDRegistry[id]
+	if !exists {
+		return fmt.Errorf("RDD %d not found", id)
+	}
+
+	counted := d.transform(r, types.Transformation{Type: types.CountOp})
+
+	total := 0
+	results, err := d.runJob(counted)
+	if err != nil {
+		return err
+	}
+	for _, partial := range flatten(results) {
+		n, ok := partial.Value.(int)
+		if !ok {
+			return fmt.Errorf("count: unexpected partial result %v", partial.Value)
+		}
+		total += n
+	}
+
+	*reply = total
+	return nil
+}
+
+// Take RPC method - evalúa las particiones en orden, empezando por una y
+// ampliando la cantidad en cada ronda, hasta reunir args.N filas
+func (d *Driver) Take(args types.TakeArgs, reply *[]types.Row) error {
+	r, exists := d.RDDRegistry[args.RDDID]
+	if !exists {
+		return fmt.Errorf("RDD %d not found", args.RDDID)
+	}
+
+	taken := []types.Row{}
+	if args.N <= 0 {
+		*reply = taken
+		return nil
+	}
+
+	limited := d.transform(r, types.Transformation{
+		Type:    types.TakeOp,
+		Options: map[string]interface{}{"n": args.N},
+	})
+
+	next, batch := 0, 1
+	for next < len(limited.Partitions) && len(taken) < args.N {
+		end := min(next+batch, len(limited.Partitions))
+		indexes := []int{}
+		for i := next; i < end; i++ {
+			indexes = append(indexes, i)
+		}
+
+		results, err := d.runTasks(limited, limited.GetTasksFor(indexes))
+		if err != nil {
+			return err
+		}
+		for _, rows := range results {
+			taken = append(taken, rows...)
+		}
+		log.Printf("Take: scanned partitions %d-%d of RDD %d, %d rows so far\n", next, end-1, r.ID, len(taken))
+
+		next = end
+		batch *= takeScaleFactor
+	}
+
+	if len(taken) > args.N {
+		taken = taken[:args.N]
+	}
+	*reply = taken
+	return nil
+}
+
+// First RPC method - devuelve la primera fila del RDD
+func (d *Driver) First(id int, reply *types.Row) error {
+	var rows []types.Row
+	if err := d.Take(types.TakeArgs{RDDID: id, N: 1}, &rows); err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return fmt.Errorf("RDD %d is empty", id)
+	}
+
+	*reply = rows[0]
+	return nil
+}
+
+// Top RPC method - cada worker conserva las args.N mayores filas de su
+// partición según el comparador args.FuncName (por Value si está vacío) y el
+// driver combina esos parciales
+func (d *Driver) Top(args types.TopArgs, reply *[]types.Row) error {
+	r, exists := d.RDDRegistry[args.RDDID]
+	if !exists {
+		return fmt.Errorf("RDD %d not found", args.RDDID)
+	}
+
+	cmp := utils.CompareByValue
+	if args.FuncName != "" {
+		if err := d.checkFuncNoArgs(args.FuncName, types.TopOp); err != nil {
+			return err
+		}
+		fn, err := utils.FuncRegistry.Comparator(args.FuncName, nil, nil)
+		if err != nil {
+			return err
+		}
+		cmp = fn
+	}
+
+	top := d.transform(r, types.Transformation{
+		Type:     types.TopOp,
+		FuncName: args.FuncName,
+		Options:  map[string]interface{}{"n": args.N},
+	})
+
+	results, err := d.runJob(top)
+	if err != nil {
+		return err
+	}
+	*reply = utils.TopN(flatten(results), args.N, cmp)
+	return nil
+}
+
+// Fold RPC method - como Reduce pero parte de args.Zero en cada partición y en
+// el driver, así las particiones vacías y los RDDs vacíos devuelven Zero
+func (d *Driver) Fold(args types.FoldArgs, reply *types.Row) error {
+	return d.aggregate(types.AggregateArgs{
+		RDDID:  args.RDDID,
+		Zero:   args.Zero,
+		SeqOp:  args.FuncName,
+		CombOp: args.FuncName,
+	}, reply)
+}
+
+// Aggregate RPC method - cada worker agrega las filas de su partición a
+// args.Zero con SeqOp y el driver combina los acumuladores con CombOp
+func (d *Driver) Aggregate(args types.AggregateArgs, reply *types.Row) error {
+	return d.aggregate(args, reply)
+}
+
+func (d *Driver) aggregate(args types.AggregateArgs, reply *types.Row) error {
+	r, exists := d.RDDRegistry[args.RDDID]
+	if !exists {
+		return fmt.Errorf("RDD %d not found", args.RDDID)
+	}
+	if err := d.checkFuncNoArgs(args.SeqOp, types.FoldOp); err != nil {
+		return err
+	}
+	if err := d.checkFuncNoArgs(args.CombOp, types.ReduceOp); err != nil {
+		return err
+	}
+	// CombOp corre en el driver, se resuelve antes de ejecutar el job
+	combOp, err := utils.FuncRegistry.Reducer(args.CombOp, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	folded := d.transform(r, types.Transformation{
+		Type:     types.FoldOp,
+		FuncName: args.SeqOp,
+		Options:  map[string]interface{}{"zero": args.Zero},
+	})
+	results, err := d.runJob(folded)
+	if err != nil {
+		return err
+	}
+	partials := flatten(results)
+	log.Printf("Partial results: %v\n", partials)
+
+	*reply = utils.Fold(partials, args.Zero, combOp)
+	return nil
+}
+
+// Stats RPC method - cada worker arma un utils.StatCounter sobre el valor
+// numérico de sus filas y el driver combina los contadores
+func (d *Driver) Stats(args types.StatsArgs, reply *types.StatsReply) error {
+	r, exists := d.RDDRegistry[args.RDDID]
+	if !exists {
+		return fmt.Errorf("RDD %d not found", args.RDDID)
+	}
+
+	stats, err := d.stats(r, args.Field)
+	if err != nil {
+		return err
+	}
+
+	*reply = types.StatsReply{
+		Count:    stats.Count,
+		Sum:      stats.Sum,
+		Mean:     stats.Mean,
+		Variance: stats.Variance(),
+		Stdev:    stats.Stdev(),
+		Min:      stats.Min,
+		Max:      stats.Max,
+		Skipped:  stats.Skipped,
+	}
+	return nil
+}
+
+func (d *Driver) stats(r *RDD, field string) (utils.StatCounter, error) {
+	counted := d.transform(r, types.Transformation{
+		Type:    types.StatsOp,
+		Options: map[string]interface{}{"field": field},
+	})
+
+	total := utils.NewStatCounter()
+	results, err := d.runJob(counted)
+	if err != nil {
+		return total, err
+	}
+	for _, partial := range flatten(results) {
+		counter, ok := partial.Value.(utils.StatCounter)
+		if !ok {
+			return total, fmt.Errorf("stats: unexpected partial result %T", partial.Value)
+		}
+		total.Merge(counter)
+	}
+	return total, nil
+}
+
+// Histogram RPC method - cuenta cuántos valores caen en cada intervalo, los
+// workers cuentan sus particiones y el driver suma los conteos
+func (d *Driver) Histogram(args types.HistogramArgs, reply *types.HistogramReply) error {
+	r, exists := d.RDDRegistry[args.RDDID]
+	if !exists {
+		return fmt.Errorf("RDD %d not found", args.RDDID)
+	}
+
+	edges := args.Edges
+	if len(edges) == 0 {
+		if args.Buckets <= 0 {
+			return fmt.Errorf("histogram needs edges or a positive number of buckets")
+		}
+		stats, err := d.stats(r, args.Field)
+		if err != nil {
+			return err
+		}
+		if stats.Count == 0 {
+			return fmt.Errorf("cannot build a histogram of RDD %d: it has no numeric values", r.ID)
+		}
+		edges = evenEdges(stats.Min, stats.Max, args.Buckets)
+	} else if !sort.Float64sAreSorted(edges) || len(edges) < 2 {
+		return fmt.Errorf("histogram edges must be at least two sorted values")
+	}
+
+	counted := d.transform(r, types.Transformation{
+		Type:    types.HistogramOp,
+		Options: map[string]interface{}{"field": args.Field, "edges": edges},
+	})
+
+	counts := make([]int, len(edges)-1)
+	results, err := d.runJob(counted)
+	if err != nil {
+		return err
+	}
+	for _, partial := range flatten(results) {
+		partialCounts, ok := partial.Value.([]int)
+		if !ok || len(partialCounts) != len(counts) {
+			return fmt.Errorf("histogram: unexpected partial result %v", partial.Value)
+		}
+		for i, c := range partialCounts {
+			counts[i] += c
+		}
+	}
+
+	*reply = types.HistogramReply{Edges: edges, Counts: counts}
+	return nil
+}
+
+// evenEdges divide [lo, hi] en buckets intervalos iguales
+func evenEdges(lo, hi float64, buckets int) []float64 {
+	if lo == hi {
+		return []float64{lo, hi}
+	}
+	edges := make([]float64, buckets+1)
+	for i := range edges {
+		edges[i] = lo + (hi-lo)*float64(i)/float64(buckets)
+	}
+	edges[buckets] = hi
+	return edges
+}
+
+// CountApproxDistinct RPC method - cada worker arma un HyperLogLog de su
+// partición y el driver los combina, con memoria acotada por la precisión
+func (d *Driver) CountApproxDistinct(args types.ApproxDistinctArgs, reply *int64) error {
+	r, exists := d.RDDRegistry[args.RDDID]
+	if !exists {
+		return fmt.Errorf("RDD %d not found", args.RDDID)
+	}
+
+	relativeSD := args.RelativeSD
+	if relativeSD == 0 {
+		relativeSD = defaultRelativeSD
+	}
+	precision := utils.HLLPrecision(relativeSD)
+
+	sketched := d.transform(r, types.Transformation{
+		Type:    types.ApproxDistinctOp,
+		Options: map[string]interface{}{"field": args.Field, "precision": precision},
+	})
+
+	total := utils.NewHyperLogLog(precision)
+	results, err := d.runJob(sketched)
+	if err != nil {
+		return err
+	}
+	for _, partial := range flatten(results) {
+		sketch, ok := partial.Value.(utils.HyperLogLog)
+		if !ok {
+			return fmt.Errorf("countApproxDistinct: unexpected partial result %T", partial.Value)
+		}
+		if err := total.Merge(sketch); err != nil {
+			return err
+		}
+	}
+
+	*reply = int64(math.Round(total.Estimate()))
+	return nil
+}
+
+// ApproxQuantile RPC method - cada worker arma un sketch KLL del valor numérico
+// de su partición y el driver los combina para responder los cuantiles pedidos
+func (d *Driver) ApproxQuantile(args types.ApproxQuantileArgs, reply *[]float64) error {
+	r, exists := d.RDDRegistry[args.RDDID]
+	if !exists {
+		return fmt.Errorf("RDD %d not found", args.RDDID)
+	}
+	for _, q := range args.Probabilities {
+		if q < 0 || q > 1 {
+			return fmt.Errorf("invalid quantile probability %v", q)
+		}
+	}
+
+	k := args.K
+	if k <= 0 {
+		k = defaultSketchK
+	}
+
+	sketched := d.transform(r, types.Transformation{
+		Type:    types.QuantileSketchOp,
+		Options: map[string]interface{}{"field": args.Field, "k": k},
+	})
+
+	total := utils.NewQuantileSketch(k)
+	results, err := d.runJob(sketched)
+	if err != nil {
+		return err
+	}
+	for _, partial := range flatten(results) {
+		sketch, ok := partial.Value.(utils.QuantileSketch)
+		if !ok {
+			return fmt.Errorf("approxQuantile: unexpected partial result %T", partial.Value)
+		}
+		if err := total.Merge(sketch); err != nil {
+			return err
+		}
+	}
+	if total.Count == 0 {
+		return fmt.Errorf("cannot compute quantiles of RDD %d: it has no numeric values", r.ID)
+	}
+
+	quantiles := make([]float64, len(args.Probabilities))
+	for i, q := range args.Probabilities {
+		quantiles[i] = total.Quantile(q)
+	}
+	*reply = quantiles
+	return nil
+}
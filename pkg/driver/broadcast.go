@@ -0,0 +1,311 @@
+package driver
+
+import (
+	"Go-Mini-Spark/pkg/types"
+	"Go-Mini-Spark/pkg/utils"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// DefaultBroadcastJoinThreshold es el valor por defecto de
+// Driver.BroadcastJoinThreshold (10 MB)
+const DefaultBroadcastJoinThreshold = 10 * 1024 * 1024
+
+// expandingOps son las transformaciones que pueden producir más filas de las
+// que reciben, el tamaño de un RDD que las aplica no se puede estimar
+var expandingOps = map[types.TransformationType]bool{
+	types.FlatMapOp:                true,
+	types.FlatMapValuesOp:          true,
+	types.MapPartitionsOp:          true,
+	types.MapPartitionsWithIndexOp: true,
+	types.BroadcastJoinOp:          true,
+	types.PipeOp:                   true,
+}
+
+// estimateRDDSize estima los bytes de r sumando los tamaños que PartitionCache
+// registró (ver estimateSize) para las particiones de origen. ok es false si
+// algún paso del linaje puede aumentar la cantidad de filas.
+func (d *Driver) estimateRDDSize(r *RDD) (size int64, ok bool) {
+	for curr := r; curr != nil; curr = curr.Parent {
+		for _, t := range curr.Transformations {
+			if expandingOps[t.Type] {
+				return 0, false
+			}
+		}
+
+		if len(curr.Sources) > 0 {
+			for _, source := range curr.Sources {
+				sourceSize, sourceOK := d.estimateRDDSize(source)
+				if !sourceOK {
+					return 0, false
+				}
+				size += sourceSize
+			}
+			return size, true
+		}
+
+		if curr.Parent == nil {
+			for _, partitionID := range curr.Partitions {
+				size += d.Cache.Size(partitionID)
+			}
+		}
+	}
+	return size, true
+}
+
+// chooseBroadcast decide si Join puede difundir uno de los lados: debe estar
+// bajo BroadcastJoinThreshold y el tipo de join debe poder resolverse en cada
+// partición del otro lado (por ejemplo un left outer join solo puede difundir
+// el lado derecho). Si ambos califican se difunde el más chico.
+func (d *Driver) chooseBroadcast(left, right *RDD, joinType types.JoinType) (broadcastLeft bool, ok bool) {
+	if d.BroadcastJoinThreshold <= 0 {
+		return false, false
+	}
+
+	canLeft := joinType == types.InnerJoin || joinType == types.RightOuterJoin
+	canRight := joinType != types.RightOuterJoin && joinType != types.FullOuterJoin
+
+	leftSize, leftKnown := d.estimateRDDSize(left)
+	rightSize, rightKnown := d.estimateRDDSize(right)
+	canLeft = canLeft && leftKnown && leftSize < d.BroadcastJoinThreshold
+	canRight = canRight && rightKnown && rightSize < d.BroadcastJoinThreshold
+
+	switch {
+	case canLeft && canRight:
+		return leftSize < rightSize, true
+	case canLeft:
+		return true, true
+	case canRight:
+		return false, true
+	}
+	return false, false
+}
+
+// joinBroadcastPrefix es el prefijo de los IDs de los broadcasts que publica
+// broadcastJoin, Broadcast no acepta IDs con ese prefijo
+const joinBroadcastPrefix = "join-"
+
+// joinBroadcast es el lado chico de un broadcast join y la cantidad de jobs en
+// curso que usan sus filas publicadas
+type joinBroadcast struct {
+	small *RDD
+	jobs  int
+}
+
+// broadcastJoin registra un RDD que hace el join en cada partición del lado
+// grande sin redistribuirlo, con las filas del lado chico difundidas como
+// broadcast. El lado chico se evalúa y publica al comenzar cada job que usa el
+// RDD y se libera al terminar (ver acquireJoinBroadcasts). Cada worker pide las
+// filas difundidas una sola vez por job (ver Driver.GetBroadcast).
+func (d *Driver) broadcastJoin(left, right *RDD, broadcastLeft bool, request types.JoinRequest) *RDD {
+	small, large := right, left
+	if broadcastLeft {
+		small, large = left, right
+	}
+
+	id := fmt.Sprintf("%s%d", joinBroadcastPrefix, newID())
+	d.BroadcastMutex.Lock()
+	d.joinBroadcasts[id] = &joinBroadcast{small: small}
+	d.BroadcastMutex.Unlock()
+
+	log.Printf("Broadcast join: RDD %d will be broadcast as %s, probing RDD %d\n", small.ID, id, large.ID)
+
+	return d.transform(large, types.Transformation{
+		Type: types.BroadcastJoinOp,
+		Options: map[string]interface{}{
+			"broadcastID":   id,
+			"broadcastLeft": broadcastLeft,
+			"joinType":      int(request.JoinType),
+			"collision":     int(request.Collision),
+		},
+	})
+}
+
+// acquireJoinBroadcasts publica el lado chico de cada broadcast join que usan
+// tasks, evaluándolo si ningún otro job en curso ya lo publicó. La función que
+// retorna los libera cuando termina el job: el último job que usa un broadcast
+// lo borra del driver y de los workers.
+func (d *Driver) acquireJoinBroadcasts(tasks []types.Task) (func(), error) {
+	acquired := []string{}
+	release := func() {
+		for _, id := range acquired {
+			d.releaseJoinBroadcast(id)
+		}
+	}
+
+	for _, id := range joinBroadcastIDs(tasks) {
+		if err := d.acquireJoinBroadcast(id); err != nil {
+			release()
+			return nil, err
+		}
+		acquired = append(acquired, id)
+	}
+	return release, nil
+}
+
+func (d *Driver) acquireJoinBroadcast(id string) error {
+	d.BroadcastMutex.Lock()
+	jb, exists := d.joinBroadcasts[id]
+	if !exists {
+		d.BroadcastMutex.Unlock()
+		return fmt.Errorf("broadcast join '%s' not found", id)
+	}
+	if jb.jobs > 0 {
+		jb.jobs++
+		d.BroadcastMutex.Unlock()
+		return nil
+	}
+	d.BroadcastMutex.Unlock()
+
+	// el lado chico se evalúa sin el lock, puede ser a su vez un broadcast join
+	results, err := d.runJob(jb.small)
+	if err != nil {
+		return fmt.Errorf("broadcast join side RDD %d: %w", jb.small.ID, err)
+	}
+	rows := flatten(results)
+
+	d.BroadcastMutex.Lock()
+	defer d.BroadcastMutex.Unlock()
+	if jb.jobs == 0 {
+		d.Broadcasts[id] = rows
+		log.Printf("Published broadcast %s with %d rows of RDD %d\n", id, len(rows), jb.small.ID)
+	}
+	jb.jobs++
+	return nil
+}
+
+func (d *Driver) releaseJoinBroadcast(id string) {
+	d.BroadcastMutex.Lock()
+	jb := d.joinBroadcasts[id]
+	jb.jobs--
+	last := jb.jobs == 0
+	if last {
+		delete(d.Broadcasts, id)
+	}
+	d.BroadcastMutex.Unlock()
+
+	if last {
+		d.unbroadcast(id)
+	}
+}
+
+// joinBroadcastIDs retorna los IDs de broadcast de los broadcast joins de
+// tasks, incluidas las tasks que reúnen
+func joinBroadcastIDs(tasks []types.Task) []string {
+	seen := make(map[string]bool)
+	var ids []string
+	var visit func(task types.Task)
+	visit = func(task types.Task) {
+		for _, t := range task.Transformations {
+			id, ok := t.Options["broadcastID"].(string)
+			if t.Type == types.BroadcastJoinOp && ok && !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+		for _, input := range task.Inputs {
+			visit(input)
+		}
+	}
+	for _, task := range tasks {
+		visit(task)
+	}
+	return ids
+}
+
+// fetchBroadcast retorna el valor publicado con id, utils lo llama la primera
+// vez que una función que corre en el driver lo necesita
+func (d *Driver) fetchBroadcast(id string) (interface{}, error) {
+	d.BroadcastMutex.RLock()
+	defer d.BroadcastMutex.RUnlock()
+
+	value, exists := d.Broadcasts[id]
+	if !exists {
+		return nil, fmt.Errorf("broadcast '%s' not found", id)
+	}
+	return value, nil
+}
+
+// unbroadcast borra id de la caché del driver y de los workers registrados. Un
+// worker que no responde conserva su copia, se registra en el log.
+func (d *Driver) unbroadcast(id string) {
+	utils.DropBroadcast(id)
+	for _, workerID := range keys(d.Workers) {
+		var reply bool
+		if err := callWorker(d.Workers[workerID].Endpoint, "Worker.DropBroadcast", id, &reply); err != nil {
+			log.Printf("Cannot drop broadcast %s from worker %d: %v\n", id, workerID, err)
+		}
+	}
+}
+
+// publish guarda value bajo id, retorna false si el ID ya estaba en uso
+func (d *Driver) publish(id string, value interface{}) bool {
+	d.BroadcastMutex.Lock()
+	defer d.BroadcastMutex.Unlock()
+
+	if _, exists := d.Broadcasts[id]; exists {
+		return false
+	}
+	d.Broadcasts[id] = value
+	return true
+}
+
+// Broadcast RPC method - publica un valor de solo lectura (un mapa de búsqueda,
+// una lista de stop words, pesos de un modelo) que las funciones de
+// utils.FuncRegistry leen con utils.BroadcastValue. Un ID no se puede redefinir
+// mientras no se destruya con DestroyBroadcast, porque los workers conservan el
+// valor que ya descargaron.
+func (d *Driver) Broadcast(args types.BroadcastVar, reply *bool) error {
+	if args.ID == "" {
+		return fmt.Errorf("broadcast ID cannot be empty")
+	}
+	if strings.HasPrefix(args.ID, joinBroadcastPrefix) {
+		return fmt.Errorf("broadcast IDs starting with '%s' are reserved for broadcast joins", joinBroadcastPrefix)
+	}
+	if !d.publish(args.ID, args.Value) {
+		return fmt.Errorf("broadcast '%s' already exists", args.ID)
+	}
+
+	log.Printf("Published broadcast %s\n", args.ID)
+	*reply = true
+	return nil
+}
+
+// DestroyBroadcast RPC method - borra el valor publicado con Broadcast del
+// driver y de la caché de los workers, así deja de ocupar memoria y el ID se
+// puede volver a publicar. Después las funciones que lo usan no lo encuentran
+// (utils.BroadcastValue retorna false).
+func (d *Driver) DestroyBroadcast(id string, reply *bool) error {
+	if strings.HasPrefix(id, joinBroadcastPrefix) {
+		return fmt.Errorf("broadcast '%s' belongs to a broadcast join and is released by the driver", id)
+	}
+
+	d.BroadcastMutex.Lock()
+	_, exists := d.Broadcasts[id]
+	delete(d.Broadcasts, id)
+	d.BroadcastMutex.Unlock()
+	if !exists {
+		return fmt.Errorf("broadcast '%s' not found", id)
+	}
+
+	d.unbroadcast(id)
+	log.Printf("Destroyed broadcast %s\n", id)
+	*reply = true
+	return nil
+}
+
+// GetBroadcast RPC method - retorna el valor difundido con el ID dado, los
+// workers lo llaman la primera vez que ejecutan una task que lo usa
+func (d *Driver) GetBroadcast(id string, reply *types.BroadcastVar) error {
+	d.BroadcastMutex.RLock()
+	defer d.BroadcastMutex.RUnlock()
+
+	value, exists := d.Broadcasts[id]
+	if !exists {
+		return fmt.Errorf("broadcast '%s' not found", id)
+	}
+
+	*reply = types.BroadcastVar{ID: id, Value: value}
+	return nil
+}
@@ -49,25 +49,74 @@ func NewPartitionCache(dir string, maxMem int64) (*PartitionCache, error) {
 	}, nil
 }
 
-// estimateSizeSimple calculates basic string content size without encoding overhead
+// rawSize approximates the content size of a row key or value: the length of
+// strings, 8 bytes per number, and the contents of maps, slices and nested rows
+// (CSV rows are map[string]interface{}, GroupByKey values are []interface{}).
+func rawSize(v interface{}) int64 {
+    switch v := v.(type) {
+    case nil:
+        return 0
+    case string:
+        return int64(len(v))
+    case bool:
+        return 1
+    case types.Row:
+        return rawSize(v.Key) + rawSize(v.Value)
+    case []types.Row:
+        var size int64
+        for _, row := range v {
+            size += rawSize(row)
+        }
+        return size
+    case []interface{}:
+        var size int64
+        for _, item := range v {
+            size += rawSize(item)
+        }
+        return size
+    case []string:
+        var size int64
+        for _, item := range v {
+            size += int64(len(item))
+        }
+        return size
+    case map[string]interface{}:
+        var size int64
+        for key, item := range v {
+            size += int64(len(key)) + rawSize(item)
+        }
+        return size
+    case map[string]string:
+        var size int64
+        for key, item := range v {
+            size += int64(len(key) + len(item))
+        }
+        return size
+    case map[string]float64:
+        var size int64
+        for key := range v {
+            size += int64(len(key)) + 8
+        }
+        return size
+    default:
+        // numbers and other fixed-size values
+        return 8
+    }
+}
+
+// estimateSizeSimple calculates the content size (see rawSize) without encoding overhead
 func estimateSizeSimple(data []types.Row) int64 {
     var totalSize int64
     for _, row := range data {
-        // Count raw string content only
-        if keyStr, ok := row.Key.(string); ok {
-            totalSize += int64(len(keyStr))
-        }
-        if valueStr, ok := row.Value.(string); ok {
-            totalSize += int64(len(valueStr))
-        }
+        totalSize += rawSize(row.Key) + rawSize(row.Value)
         // Add basic struct overhead (rough estimate)
         totalSize += 32 // estimated per-row overhead
     }
     return totalSize
 }
 
-// estimateSize calculates the approximate memory footprint of string slice data.
-// Includes string content length plus estimated overhead (16 bytes per string for slice header/pointer).
+// estimateSize calculates the approximate memory footprint of data: the content
+// size of every row (see rawSize) scaled by the gob overhead of a sample.
 func estimateSize(data []types.Row) int64 {
     // Option 1: Sample-based estimation
     if len(data) == 0 {
@@ -83,28 +132,18 @@ func estimateSize(data []types.Row) int64 {
     encoder.Encode(sample)
     
     // Calculate overhead ratio
-    rawSize := int64(0)
+    sampleRawSize := int64(0)
     for _, row := range sample {
-        if keyStr, ok := row.Key.(string); ok {
-            rawSize += int64(len(keyStr))
-        }
-        if valueStr, ok := row.Value.(string); ok {
-            rawSize += int64(len(valueStr))
-        }
+        sampleRawSize += rawSize(row.Key) + rawSize(row.Value)
     }
     
-    if rawSize > 0 {
-        overhead := float64(buffer.Len()) / float64(rawSize)
+    if sampleRawSize > 0 {
+        overhead := float64(buffer.Len()) / float64(sampleRawSize)
         
         // Apply overhead to full dataset
         totalRawSize := int64(0)
         for _, row := range data {
-            if keyStr, ok := row.Key.(string); ok {
-                totalRawSize += int64(len(keyStr))
-            }
-            if valueStr, ok := row.Value.(string); ok {
-                totalRawSize += int64(len(valueStr))
-            }
+            totalRawSize += rawSize(row.Key) + rawSize(row.Value)
         }
         
         return int64(float64(totalRawSize) * overhead)
@@ -204,6 +243,32 @@ func (c *PartitionCache) Get(partitionID int) []types.Row {
 	return data
 }
 
+// Size returns the estimated size in bytes of a partition, as computed by
+// estimateSize when it was stored. For a spilled partition the size of its file
+// on disk is used. Unknown partitions have size 0.
+func (c *PartitionCache) Size(partitionID int) int64 {
+	c.mu.RLock()
+	entry, exists := c.entries[partitionID]
+	c.mu.RUnlock()
+
+	if !exists {
+		return 0
+	}
+
+	entry.mu.RLock()
+	defer entry.mu.RUnlock()
+
+	if entry.inMem != nil || entry.onDiskPath == "" {
+		return entry.sizeBytes
+	}
+	info, err := os.Stat(entry.onDiskPath)
+	if err != nil {
+		log.Printf("Error reading size of partition file %s: %v", entry.onDiskPath, err)
+		return 0
+	}
+	return info.Size()
+}
+
 // loadFromDisk reads and deserializes partition data from a gob-encoded file.
 // Returns nil if the file cannot be read or decoded.
 func (c *PartitionCache) loadFromDisk(path string) []types.Row {
@@ -20,6 +20,7 @@ var rddCounter uint64
 
 const WorkerTimeoutSeconds = 10
 const maxMem = 100 * 1024 * 1024
+const defaultPartitions = 4
 
 type Driver struct {
 	Workers         map[int]types.WorkerInfo
@@ -34,6 +35,16 @@ type Driver struct {
 	Cache           *PartitionCache
 	StateDir        string
 	WorkerMutex     sync.Mutex
+
+	// BroadcastJoinThreshold es el tamaño estimado en bytes bajo el cual Join
+	// difunde un lado a los workers en vez de redistribuir ambos, 0 lo desactiva
+	BroadcastJoinThreshold int64
+	Broadcasts             map[string]interface{} // valores difundidos por ID
+	BroadcastMutex         sync.RWMutex
+	joinBroadcasts         map[string]*joinBroadcast // lado chico de cada broadcast join, por ID de broadcast
+
+	accumulators     map[string]*accumulator
+	AccumulatorMutex sync.Mutex
 }
 // Source - https://stackoverflow.com/a
 // Posted by Andrew
@@ -41,6 +52,15 @@ type Driver struct {
 func init() {
 	gob.Register(types.Row{})
 	gob.Register(map[string]any{})
+	gob.Register([]interface{}{})
+	gob.Register(utils.StatCounter{})
+	gob.Register(utils.HyperLogLog{})
+	gob.Register(utils.QuantileSketch{})
+	gob.Register([]types.Row{})
+	gob.Register([]string{})
+	gob.Register([]float64{})
+	gob.Register(map[string]string{})
+	gob.Register(map[string]float64{})
 }
 
 func newID() int {
@@ -66,6 +86,12 @@ func NewDriver(port string) *Driver {
 		Port:          port,
 		StateDir:      "driver_state",
 		Cache:         cache,
+
+		BroadcastJoinThreshold: DefaultBroadcastJoinThreshold,
+		Broadcasts:             make(map[string]interface{}),
+		joinBroadcasts:         make(map[string]*joinBroadcast),
+
+		accumulators: make(map[string]*accumulator),
 	}
 }
 
@@ -116,7 +142,7 @@ func (d *Driver) RegisterRDD(r *RDD) {
 	d.RDDRegistry[r.ID] = r
 
 	// If root RDD, allocate partitions
-	if r.Parent == nil {
+	if r.Parent == nil && len(r.Sources) == 0 {
 		d.allocatePartitions(r)
 	}
 }
@@ -126,15 +152,9 @@ func (d *Driver) splitAndStoreData(r *RDD, rows []types.Row) {
         panic("RDD.NumPartitions not set")
     }
 
-    chunkSize := len(rows) / r.NumPartitions
-
     for i := 0; i < r.NumPartitions; i++ {
-        start := i * chunkSize
-        end := start + chunkSize
-
-        if i == r.NumPartitions-1 {
-            end = len(rows)
-        }
+        start := i * len(rows) / r.NumPartitions
+        end := (i + 1) * len(rows) / r.NumPartitions
 
         dataChunk := rows[start:end]
         partitionID := r.Partitions[i]
@@ -143,17 +163,25 @@ func (d *Driver) splitAndStoreData(r *RDD, rows []types.Row) {
     }
 }
 
-func (d *Driver) ReadRDDTextFile(filename string, reply *int) error {
-    dataBytes, err := os.ReadFile(filename)
+// partitionsOrDefault devuelve n, o defaultPartitions si no se indicó una cantidad
+func partitionsOrDefault(n int) int {
+    if n <= 0 {
+        return defaultPartitions
+    }
+    return n
+}
+
+func (d *Driver) ReadRDDTextFile(arg types.ReadTextArg, reply *int) error {
+    dataBytes, err := os.ReadFile(arg.FilePath)
     if err != nil {
-        return fmt.Errorf("error reading file %s: %w", filename, err)
+        return fmt.Errorf("error reading file %s: %w", arg.FilePath, err)
     }
     
     lines := strings.Split(string(dataBytes), "\n")
     rdd := &RDD{
         ID:              newID(),
         Parent:          nil,
-        NumPartitions:   4,
+        NumPartitions:   partitionsOrDefault(arg.NumPartitions),
         Transformations: []types.Transformation{},
     }
 
@@ -216,7 +244,7 @@ func (d *Driver) ReadCSV(arg types.ReadCSVArg, reply *int) error {
     rdd := &RDD{
         ID:              newID(),
         Parent:          nil,
-        NumPartitions:   4,
+        NumPartitions:   partitionsOrDefault(arg.NumPartitions),
         Transformations: []types.Transformation{},
     }
 	
@@ -234,6 +262,10 @@ func (m *Driver) Start() {
 
 	m.StartWorkerMonitoring()
 
+	// las funciones que corre el propio driver (Reduce, Top, las claves de
+	// SortBy...) leen los broadcasts de Broadcasts en vez de pedirlos por RPC
+	utils.SetBroadcastFetcher(m.fetchBroadcast)
+
 	rpc.Register(m)
 	listener, err := net.Listen("tcp", ":"+m.Port)
 	if err != nil {
@@ -33,11 +33,13 @@ func (d *Driver) WorkerHeartbeat(heartbeat types.Heartbeat, reply *bool) error {
 			Endpoint: heartbeat.Endpoint,
 			Status:   200,
 			LastSeen: time.Now(),
+			Plugins:  heartbeat.Plugins,
 		}
 		return fmt.Errorf("worker %d not found", heartbeat.ID)
 	}
 
 	worker.LastSeen = time.Now()
+	worker.Plugins = heartbeat.Plugins
 	d.Workers[heartbeat.ID] = worker
 	log.Printf("Received heartbeat from worker %d (Active tasks: %d)\n", heartbeat.ID, heartbeat.ActiveTasks)
 	*reply = true
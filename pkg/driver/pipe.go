@@ -0,0 +1,41 @@
+package driver
+
+import (
+	"Go-Mini-Spark/pkg/types"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultPipeTimeout es el tiempo máximo que el ejecutable de Pipe puede tardar
+// con una partición si la solicitud no indica otro
+const DefaultPipeTimeout = 60 * time.Second
+
+// Pipe RPC method - registra un RDD hijo cuyas filas son las que escribe
+// args.Command al recibir las filas de cada partición. El ejecutable se busca
+// en el directorio -pipe-dir de cada worker, nunca en una ruta arbitraria. Si
+// el ejecutable falla, la acción que evalúa el RDD retorna el error con el final
+// de su stderr.
+func (d *Driver) Pipe(args types.PipeArgs, reply *int) error {
+	r, exists := d.RDDRegistry[args.RDDID]
+	if !exists {
+		return fmt.Errorf("RDD %d not found", args.RDDID)
+	}
+	if args.Command == "" || args.Command != filepath.Base(args.Command) || strings.HasPrefix(args.Command, ".") {
+		return fmt.Errorf("pipe command must be the name of an executable in the worker pipe directory, got %q", args.Command)
+	}
+
+	timeout := int(DefaultPipeTimeout / time.Second)
+	if args.Timeout > 0 {
+		timeout = args.Timeout
+	}
+	options := map[string]interface{}{"command": args.Command, "timeout": timeout}
+	if len(args.Args) > 0 {
+		options["args"] = args.Args
+	}
+
+	newRDD := d.transform(r, types.Transformation{Type: types.PipeOp, Options: options})
+	*reply = newRDD.ID
+	return nil
+}
@@ -0,0 +1,184 @@
+package driver
+
+import (
+	"Go-Mini-Spark/pkg/types"
+	"Go-Mini-Spark/pkg/utils"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+// LoadPlugins carga los plugins .so de dir en el driver. Solo hacen falta para
+// las funciones que ejecuta el propio driver (el combOp de Reduce y Aggregate,
+// el comparador de Top y la key de SortBy); el resto de las funciones de un
+// plugin basta con que las tengan los workers.
+func (d *Driver) LoadPlugins(dir string) error {
+	paths, err := utils.PluginFiles(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		info, err := utils.LoadPlugin(path)
+		if err != nil {
+			log.Printf("Skipping plugin %s: %v\n", path, err)
+			continue
+		}
+		log.Printf("Loaded plugin %s with %d functions\n", info.Name, len(info.Functions))
+	}
+	return nil
+}
+
+// checkFunc verifica que name exista en utils.FuncRegistry y que su clase
+// corresponda al tipo de transformación op, antes de registrar el RDD. Las
+// funciones de un plugin que el driver no cargó se buscan en los plugins que
+// reportan los workers.
+func (d *Driver) checkFunc(name string, op types.TransformationType) error {
+	kind, ok := opFuncKind[op]
+	if !ok {
+		return fmt.Errorf("unsupported transformation type %d", op)
+	}
+
+	namespace := utils.PluginNamespace(name)
+	if _, local := utils.FuncRegistry.Get(name); local || namespace == "" {
+		return utils.FuncRegistry.Check(name, kind)
+	}
+
+	info, found := d.pluginFunc(name)
+	if !found {
+		return fmt.Errorf("function '%s' not found in plugin '%s' of any alive worker", name, namespace)
+	}
+	if info.Kind != kind {
+		return fmt.Errorf("function '%s' is a %s, expected a %s", name, info.Kind, kind)
+	}
+	return nil
+}
+
+// checkFuncNoArgs es checkFunc para las solicitudes que no llevan argumentos
+// (CombineByKey, Fold, Aggregate, SortBy y Top): además rechaza las funciones
+// parametrizadas, que de lo contrario recibirían args nil en cada fila
+func (d *Driver) checkFuncNoArgs(name string, op types.TransformationType) error {
+	if err := d.checkFunc(name, op); err != nil {
+		return err
+	}
+
+	var info types.FuncInfo
+	if f, local := utils.FuncRegistry.Get(name); local {
+		info = f.FuncInfo
+	} else {
+		info, _ = d.pluginFunc(name)
+	}
+	if info.Parameterized {
+		return fmt.Errorf("function '%s' takes arguments, which this operation cannot pass", name)
+	}
+	return nil
+}
+
+// checkArgs verifica los argumentos de name antes de registrar el RDD, así un
+// argumento faltante o un patrón inválido se rechaza en el RPC y no al evaluar
+// cada fila. Las funciones de plugins que el driver no cargó no se verifican.
+func checkArgs(name string, args []interface{}) error {
+	if _, local := utils.FuncRegistry.Get(name); !local {
+		return nil
+	}
+	return utils.FuncRegistry.CheckArgs(name, args)
+}
+
+// workerPlugins retorna las funciones de los plugins de los workers vivos, por
+// nombre, y los namespaces que tiene cada worker
+func (d *Driver) workerPlugins() (map[string]types.FuncInfo, map[int]map[string]bool) {
+	alive := d.GetAliveWorkers()
+
+	d.WorkerMutex.Lock()
+	defer d.WorkerMutex.Unlock()
+
+	funcs := make(map[string]types.FuncInfo)
+	namespaces := make(map[int]map[string]bool)
+	for _, workerID := range alive {
+		namespaces[workerID] = make(map[string]bool)
+		for _, p := range d.Workers[workerID].Plugins {
+			namespaces[workerID][p.Name] = true
+			for _, f := range p.Functions {
+				funcs[f.Name] = f
+			}
+		}
+	}
+	return funcs, namespaces
+}
+
+// pluginFunc busca name entre las funciones de los plugins de los workers vivos
+func (d *Driver) pluginFunc(name string) (types.FuncInfo, bool) {
+	funcs, _ := d.workerPlugins()
+	info, found := funcs[name]
+	return info, found
+}
+
+// taskPlugins retorna los namespaces de los plugins cuyas funciones usa task,
+// incluidas las tasks que reúne
+func taskPlugins(task types.Task) []string {
+	required := make(map[string]bool)
+	for _, t := range task.Transformations {
+		names := []string{t.FuncName}
+		if createCombiner, ok := t.Options["createCombiner"].(string); ok {
+			names = append(names, createCombiner)
+		}
+		for _, name := range names {
+			if namespace := utils.PluginNamespace(name); namespace != "" {
+				required[namespace] = true
+			}
+		}
+	}
+	for _, input := range task.Inputs {
+		for _, namespace := range taskPlugins(input) {
+			required[namespace] = true
+		}
+	}
+
+	namespaces := make([]string, 0, len(required))
+	for namespace := range required {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
+
+// workerForTask elige el worker que ejecuta task: el asignado a su partición, o
+// si a ese le falta algún plugin que usa la task, otro worker vivo que los
+// tenga todos
+func (d *Driver) workerForTask(task types.Task) (int, error) {
+	owner := d.PartitionMap[task.PartitionID]
+	required := taskPlugins(task)
+	if len(required) == 0 {
+		return owner, nil
+	}
+
+	_, namespaces := d.workerPlugins()
+	hasAll := func(workerID int) bool {
+		for _, namespace := range required {
+			if !namespaces[workerID][namespace] {
+				return false
+			}
+		}
+		return true
+	}
+	if hasAll(owner) {
+		return owner, nil
+	}
+
+	var candidates []int
+	for workerID := range namespaces {
+		if hasAll(workerID) {
+			candidates = append(candidates, workerID)
+		}
+	}
+	if len(candidates) == 0 {
+		return 0, fmt.Errorf("no alive worker has plugin(s) %s", strings.Join(required, ", "))
+	}
+
+	sort.Ints(candidates)
+	workerID := candidates[task.PartitionID%len(candidates)]
+	log.Printf("Task %d needs plugin(s) %s, sending it to worker %d instead of %d\n",
+		task.ID, strings.Join(required, ", "), workerID, owner)
+	return workerID, nil
+}
@@ -1,13 +1,16 @@
 package driver
 
 import (
+	"Go-Mini-Spark/pkg/expr"
 	"Go-Mini-Spark/pkg/types"
     "Go-Mini-Spark/pkg/utils"
 	"log"
 	"math/rand"
     "fmt"
 	"net/rpc"
+	"sort"
 	"sync"
+	"time"
 )
 
 type RDD struct {
@@ -16,24 +19,67 @@ type RDD struct {
 	Transformations []types.Transformation
 	NumPartitions   int
 	Partitions      []int // IDs de particiones
+	Sources         []*RDD // RDDs unidos por Union, sus particiones van en orden
+	Coalesced       [][]int // posiciones en Parent.Partitions que reúne cada partición, ver Coalesce
 	Driver          *Driver
+
+	// Partitioner indica en qué partición está cada key, nil si se desconoce
+	Partitioner utils.Partitioner
+}
+
+// maxTaskAttempts es la cantidad de veces que se envía una task cuyo worker no
+// responde, esperando taskRetryDelay más en cada reintento
+const maxTaskAttempts = 3
+const taskRetryDelay = 500 * time.Millisecond
+
+// preservesPartitioning son las transformaciones que no cambian la key de las
+// filas ni las mueven de partición, un RDD hijo conserva el particionamiento
+var preservesPartitioning = map[types.TransformationType]bool{
+	types.FilterOp:        true,
+	types.MapValuesOp:     true,
+	types.FlatMapValuesOp: true,
+	types.ReduceByKeyOp:   true,
+	types.CombineByKeyOp:  true,
+	types.GroupByKeyOp:    true,
+	types.SortOp:          true,
+	types.DistinctOp:      true,
+	types.SampleOp:        true,
+	types.TakeOp:          true,
+	types.BroadcastJoinOp: true,
 }
 
 func (r *RDD) GetTasks() []types.Task {
+    indexes := make([]int, len(r.Partitions))
+    for i := range indexes {
+        indexes[i] = i
+    }
+    return r.GetTasksFor(indexes)
+}
+
+// GetTasksFor crea solo las tasks de las particiones indicadas (posiciones en r.Partitions)
+func (r *RDD) GetTasksFor(indexes []int) []types.Task {
     // construir pipeline
     pipeline := []types.Transformation{}
     curr := r
     for curr != nil {
         pipeline = append(curr.Transformations, pipeline...)
+        if len(curr.Sources) > 0 {
+            return curr.unionTasks(indexes, pipeline)
+        }
+        if len(curr.Coalesced) > 0 {
+            return curr.coalesceTasks(indexes, pipeline)
+        }
         curr = curr.Parent
     }
 
     // crear tasks
     tasks := []types.Task{}
-    for i, partitionID := range r.Partitions {
+    for _, i := range indexes {
+        partitionID := r.Partitions[i]
         tasks = append(tasks, types.Task{
             ID:              i,
             PartitionID:     partitionID,
+            PartitionIndex:  i,
             Data:            r.Driver.Cache.Get(partitionID),
             Transformations: pipeline,
         })
@@ -41,88 +87,335 @@ func (r *RDD) GetTasks() []types.Task {
     return tasks
 }
 
-func (d *Driver) SendTasks(tasks []types.Task) [][]types.Row {
+// unionTasks crea las tasks de un RDD de Union: cada partición es una partición
+// de alguna de las fuentes, con pipeline agregado a su propio pipeline
+func (r *RDD) unionTasks(indexes []int, pipeline []types.Transformation) []types.Task {
+    tasks := []types.Task{}
+    for _, i := range indexes {
+        local := i
+        for _, source := range r.Sources {
+            if local >= len(source.Partitions) {
+                local -= len(source.Partitions)
+                continue
+            }
+
+            task := source.GetTasksFor([]int{local})[0]
+            task.ID = i
+            task.PartitionIndex = i
+            task.Transformations = append(append([]types.Transformation{}, task.Transformations...), pipeline...)
+            tasks = append(tasks, task)
+            break
+        }
+    }
+    return tasks
+}
+
+// coalesceTasks crea las tasks de un RDD de Coalesce: cada partición ejecuta en
+// el worker las tasks de las particiones del padre que reúne y aplica pipeline
+// a sus filas concatenadas
+func (r *RDD) coalesceTasks(indexes []int, pipeline []types.Transformation) []types.Task {
+    tasks := []types.Task{}
+    for _, i := range indexes {
+        tasks = append(tasks, types.Task{
+            ID:              i,
+            PartitionID:     r.Partitions[i],
+            PartitionIndex:  i,
+            Inputs:          r.Parent.GetTasksFor(r.Coalesced[i]),
+            Transformations: pipeline,
+        })
+    }
+    return tasks
+}
+
+// SendTasks ejecuta cada task en el worker asignado a su partición y retorna
+// las filas de cada una. Si el worker no responde la task se reintenta hasta
+// maxTaskAttempts veces; un error de la propia task no se reintenta. Si alguna
+// task no se puede completar retorna el primer error, nunca resultados
+// parciales, y no aplica los cambios a acumuladores de ninguna task.
+func (d *Driver) SendTasks(tasks []types.Task) ([][]types.Row, error) {
     var wg sync.WaitGroup
     wg.Add(len(tasks))
 	results := make([][]types.Row, len(tasks))
+	updates := make([]map[int]types.AccumulatorUpdates, len(tasks))
+
+	var errMutex sync.Mutex
+	var firstErr error
+	fail := func(err error) {
+		errMutex.Lock()
+		defer errMutex.Unlock()
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
 
     for i, task := range tasks {
         go func(i int, task types.Task) {
             defer wg.Done()
 
-            workerID := d.PartitionMap[task.PartitionID]
-            endpoint := d.Workers[workerID].Endpoint
-
-            var rep types.TaskReply
-            client, err := rpc.Dial("tcp", endpoint)
-            if err != nil {
-                log.Printf("Worker %d unreachable: %v\n", workerID, err)
-                return
-            }
-            defer client.Close()
-
-            err = client.Call("Worker.ExecuteTask", task, &rep)
-            if err != nil {
-                log.Printf("Task %d failed: %v\n", task.ID, err)
-                return
+            for attempt := 1; attempt <= maxTaskAttempts; attempt++ {
+                rep, err := d.sendTask(task)
+                if err == nil {
+                    results[i] = rep.Data
+                    updates[i] = rep.Accumulators
+                    return
+                }
+
+                log.Printf("Task %d failed (attempt %d/%d): %v\n", task.ID, attempt, maxTaskAttempts, err)
+                if _, taskError := err.(rpc.ServerError); taskError {
+                    fail(err)
+                    return
+                }
+                if attempt == maxTaskAttempts {
+                    fail(fmt.Errorf("task %d failed after %d attempts: %w", task.ID, maxTaskAttempts, err))
+                    return
+                }
+                time.Sleep(time.Duration(attempt) * taskRetryDelay)
             }
-
-            results[i] = rep.Data
         }(i, task)
     }
 
     wg.Wait()
-    return results
+    if firstErr != nil {
+        return nil, firstErr
+    }
+    for _, taskUpdates := range updates {
+        d.mergeTaskAccumulators(taskUpdates)
+    }
+    return results, nil
 }
 
-func (d *Driver) Map(id int, reply *int) error {
-	r := d.RDDRegistry[id]
+// sendTask ejecuta una task en el worker que tiene asignada su partición
+func (d *Driver) sendTask(task types.Task) (types.TaskReply, error) {
+    var rep types.TaskReply
+    workerID, err := d.workerForTask(task)
+    if err != nil {
+        return rep, err
+    }
+    endpoint := d.Workers[workerID].Endpoint
+
+    client, err := rpc.Dial("tcp", endpoint)
+    if err != nil {
+        return rep, fmt.Errorf("worker %d unreachable: %w", workerID, err)
+    }
+    defer client.Close()
+
+    err = client.Call("Worker.ExecuteTask", task, &rep)
+    return rep, err
+}
+
+// opFuncKind es la clase de función que usa cada transformación
+var opFuncKind = map[types.TransformationType]types.FuncKind{
+	types.MapOp:                    types.MapperKind,
+	types.MapValuesOp:              types.MapperKind,
+	types.FilterOp:                 types.PredicateKind,
+	types.FlatMapOp:                types.FlatMapperKind,
+	types.FlatMapValuesOp:          types.FlatMapperKind,
+	types.ReduceOp:                 types.ReducerKind,
+	types.ReduceByKeyOp:            types.ReducerKind,
+	types.FoldOp:                   types.ReducerKind,
+	types.CombineByKeyOp:           types.ReducerKind,
+	types.SortOp:                   types.KeyExtractorKind,
+	types.KeyByOp:                  types.KeyExtractorKind,
+	types.TopOp:                    types.ComparatorKind,
+	types.MapPartitionsOp:          types.PartitionMapperKind,
+	types.MapPartitionsWithIndexOp: types.IndexedPartitionMapperKind,
+}
+
+// ListFunctions RPC method - describe las funciones de utils.FuncRegistry
+// disponibles, con su clase y si reciben argumentos, junto con las de los
+// plugins de los workers vivos
+func (d *Driver) ListFunctions(args struct{}, reply *[]types.FuncInfo) error {
+	funcs, _ := d.workerPlugins()
+	for _, f := range utils.FuncRegistry.List() {
+		funcs[f.Name] = f
+	}
+
+	list := make([]types.FuncInfo, 0, len(funcs))
+	for _, f := range funcs {
+		list = append(list, f)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	*reply = list
+	return nil
+}
+
+// transform registra un RDD hijo de r con la transformación t pendiente
+func (d *Driver) transform(r *RDD, t types.Transformation) *RDD {
 	newRDD := &RDD{
 		ID:            newID(),
 		Parent:        r,
 		NumPartitions: r.NumPartitions,
 		Partitions:    r.Partitions,
 		Driver:        r.Driver,
+
+	}
+	if preservesPartitioning[t.Type] {
+		newRDD.Partitioner = r.Partitioner
 	}
 
 	// agregamos la transformación pendiente
-	newRDD.Transformations = append(newRDD.Transformations, types.Transformation{
-		Type:     types.MapOp,
-		FuncName: "ToUpper",
-	})
+	t.RDDID = newRDD.ID
+	newRDD.Transformations = append(newRDD.Transformations, t)
 
 	// registramos el nuevo RDD en el Driver
 	d.RegisterRDD(newRDD)
+	return newRDD
+}
+
+// narrow valida la función pedida y registra el RDD hijo con la transformación op
+func (d *Driver) narrow(args types.MapArgs, op types.TransformationType, reply *int) error {
+	r, exists := d.RDDRegistry[args.RDDID]
+	if !exists {
+		return fmt.Errorf("RDD %d not found", args.RDDID)
+	}
+	if err := d.checkFunc(args.FuncName, op); err != nil {
+		return err
+	}
+	if err := checkArgs(args.FuncName, args.Args); err != nil {
+		return err
+	}
+	encoded, err := utils.EncodeArgs(args.Args)
+	if err != nil {
+		return err
+	}
+
+	newRDD := d.transform(r, types.Transformation{
+		Type:     op,
+		FuncName: args.FuncName,
+		Args:     encoded,
+	})
 
 	*reply = newRDD.ID
 	return nil
 }
 
-func (r *RDD) Filter() *RDD {
+// Map RPC method - registra un RDD hijo que aplica args.FuncName a cada fila
+func (d *Driver) Map(args types.MapArgs, reply *int) error {
+	return d.narrow(args, types.MapOp, reply)
+}
 
-	newRDD := &RDD{
-		ID:            newID(),
-		Parent:        r,
-		NumPartitions: r.NumPartitions,
-		Partitions:    r.Partitions,
-		Driver:        r.Driver,
+// Filter RPC method - registra un RDD hijo con las filas que cumplen
+// args.FuncName. Las filas descartadas se cuentan en el acumulador
+// "filter.<id>.dropped", con el ID del RDD hijo.
+func (d *Driver) Filter(args types.FilterArgs, reply *int) error {
+	if err := d.narrow(args, types.FilterOp, reply); err != nil {
+		return err
 	}
+	return d.countDropped(*reply)
+}
 
-	newRDD.Transformations = append(newRDD.Transformations, types.Transformation{
-		Type:     types.FilterOp,
-		FuncName: "IsLong",
-	})
+// countDropped crea el acumulador de filas descartadas del filtro id
+func (d *Driver) countDropped(id int) error {
+	name := droppedAccumulator(id)
+	filtered := d.RDDRegistry[id]
+	filtered.Transformations[0].Options = map[string]interface{}{"droppedAccumulator": name}
+	return d.createAccumulator(name, types.SumAccumulator)
+}
 
-	r.Driver.RegisterRDD(newRDD)
+// FlatMap RPC method - registra un RDD hijo que expande cada fila con args.FuncName
+func (d *Driver) FlatMap(args types.FlatMapArgs, reply *int) error {
+	return d.narrow(args, types.FlatMapOp, reply)
+}
 
-	return newRDD
+// MapPartitions RPC method - registra un RDD hijo que aplica args.FuncName a
+// cada partición completa
+func (d *Driver) MapPartitions(args types.MapPartitionsArgs, reply *int) error {
+	return d.narrow(args, types.MapPartitionsOp, reply)
 }
 
-func (d *Driver) Collect(id int, reply *[]types.Row) error {
-    r := d.RDDRegistry[id]
+// MapPartitionsWithIndex RPC method - igual que MapPartitions pero la función
+// recibe también la posición de la partición
+func (d *Driver) MapPartitionsWithIndex(args types.MapPartitionsArgs, reply *int) error {
+	return d.narrow(args, types.MapPartitionsWithIndexOp, reply)
+}
 
-    tasks := r.GetTasks()
+// MapValues RPC method - aplica args.FuncName a cada fila conservando su key,
+// por lo que el RDD hijo mantiene el particionamiento del padre
+func (d *Driver) MapValues(args types.MapArgs, reply *int) error {
+	return d.narrow(args, types.MapValuesOp, reply)
+}
 
+// FlatMapValues RPC method - expande cada fila con args.FuncName, las filas
+// generadas conservan la key de la fila original
+func (d *Driver) FlatMapValues(args types.FlatMapArgs, reply *int) error {
+	return d.narrow(args, types.FlatMapValuesOp, reply)
+}
+
+// KeyBy RPC method - registra un RDD hijo cuya key es el resultado de
+// args.FuncName sobre cada fila
+func (d *Driver) KeyBy(args types.MapArgs, reply *int) error {
+	return d.narrow(args, types.KeyByOp, reply)
+}
+
+// Keys RPC method - registra un RDD hijo con la key de cada fila como valor
+func (d *Driver) Keys(id int, reply *int) error {
+	return d.funcLess(id, types.KeysOp, reply)
+}
+
+// Values RPC method - registra un RDD hijo con solo el valor de cada fila
+func (d *Driver) Values(id int, reply *int) error {
+	return d.funcLess(id, types.ValuesOp, reply)
+}
+
+// narrowExpr valida la expresión pedida y registra el RDD hijo con la
+// transformación op, que el worker evalúa con pkg/expr
+func (d *Driver) narrowExpr(args types.ExprArgs, op types.TransformationType, reply *int) error {
+	r, exists := d.RDDRegistry[args.RDDID]
+	if !exists {
+		return fmt.Errorf("RDD %d not found", args.RDDID)
+	}
+	// los errores de sintaxis se reportan al enviar la transformación
+	if _, err := expr.Parse(args.Expr); err != nil {
+		return err
+	}
+
+	newRDD := d.transform(r, types.Transformation{Type: op, Expr: args.Expr})
+	*reply = newRDD.ID
+	return nil
+}
+
+// MapExpr RPC method - reemplaza el valor de cada fila por el resultado de
+// args.Expr. La key se conserva, así que se registra como MapValuesOp y el RDD
+// hijo mantiene el particionamiento del padre
+func (d *Driver) MapExpr(args types.ExprArgs, reply *int) error {
+	return d.narrowExpr(args, types.MapValuesOp, reply)
+}
+
+// FilterExpr RPC method - conserva las filas en las que args.Expr es true, con
+// el mismo acumulador de descartadas que Filter
+func (d *Driver) FilterExpr(args types.ExprArgs, reply *int) error {
+	if err := d.narrowExpr(args, types.FilterOp, reply); err != nil {
+		return err
+	}
+	return d.countDropped(*reply)
+}
+
+// KeyByExpr RPC method - registra un RDD hijo cuya key es el resultado de
+// args.Expr sobre cada fila
+func (d *Driver) KeyByExpr(args types.ExprArgs, reply *int) error {
+	return d.narrowExpr(args, types.KeyByOp, reply)
+}
+
+// funcLess registra el RDD hijo con una transformación op que no usa función
+func (d *Driver) funcLess(id int, op types.TransformationType, reply *int) error {
+	r, exists := d.RDDRegistry[id]
+	if !exists {
+		return fmt.Errorf("RDD %d not found", id)
+	}
+
+	newRDD := d.transform(r, types.Transformation{Type: op})
+	*reply = newRDD.ID
+	return nil
+}
+
+// runJob ejecuta las tasks de r como un job y retorna los resultados por partición
+func (d *Driver) runJob(r *RDD) ([][]types.Row, error) {
+    return d.runTasks(r, r.GetTasks())
+}
+
+// runTasks ejecuta un subconjunto de las tasks de r como un job. El job falla
+// con la primera task que no se pudo completar.
+func (d *Driver) runTasks(r *RDD, tasks []types.Task) ([][]types.Row, error) {
     // logging del Job
     jobID := rand.Intn(1000)
     job := types.Job{
@@ -134,52 +427,78 @@ func (d *Driver) Collect(id int, reply *[]types.Row) error {
     d.RegisterJob(job)
     d.SaveJobState(job.ID, "running")
 
-    results := d.SendTasks(tasks)
+    release, err := d.acquireJoinBroadcasts(tasks)
+    if err != nil {
+        d.SaveJobState(job.ID, "failed")
+        return nil, fmt.Errorf("job %d on RDD %d failed: %w", job.ID, r.ID, err)
+    }
+    defer release()
+
+    results, err := d.SendTasks(tasks)
+    if err != nil {
+        d.SaveJobState(job.ID, "failed")
+        return nil, fmt.Errorf("job %d on RDD %d failed: %w", job.ID, r.ID, err)
+    }
+
+    d.SaveJobState(job.ID, "completed")
+    return results, nil
+}
+
+func (d *Driver) Collect(id int, reply *[]types.Row) error {
+    r, exists := d.RDDRegistry[id]
+    if !exists {
+        return fmt.Errorf("RDD %d not found", id)
+    }
+
+    results, err := d.runJob(r)
+    if err != nil {
+        return err
+    }
 
     // aplanar resultados
 	flat := []types.Row{}
 	for _, chunk := range results {
 		flat = append(flat, chunk...)
 	}
-	*reply = flat
 
-    d.SaveJobState(job.ID, "completed")
     *reply = flat
     return nil
 }
 
-func (d *Driver) Reduce(id int, reply *[]types.Row) error {
-    r := d.RDDRegistry[id]
-
-	newRDD := &RDD{
-		ID:            newID(),
-		Parent:        r,
-		NumPartitions: r.NumPartitions,
-		Partitions:    r.Partitions,
-		Driver:        r.Driver,
-	}
+// Reduce RPC method - reduce cada partición en los workers y combina los
+// resultados parciales en el driver con la misma función
+func (d *Driver) Reduce(args types.ReduceArgs, reply *types.Row) error {
+    r, exists := d.RDDRegistry[args.RDDID]
+    if !exists {
+        return fmt.Errorf("RDD %d not found", args.RDDID)
+    }
+    if err := d.checkFunc(args.FuncName, types.ReduceOp); err != nil {
+        return err
+    }
+    if err := checkArgs(args.FuncName, args.Args); err != nil {
+        return err
+    }
+    // el driver combina los parciales, así que la función tiene que estar
+    // cargada también en él: se resuelve antes de ejecutar el job
+    fn, err := utils.FuncRegistry.Reducer(args.FuncName, nil, args.Args)
+    if err != nil {
+        return err
+    }
+    encoded, err := utils.EncodeArgs(args.Args)
+    if err != nil {
+        return err
+    }
 
-	newRDD.Transformations = append(newRDD.Transformations, types.Transformation{
+	newRDD := d.transform(r, types.Transformation{
 		Type:     types.ReduceOp,
-		FuncName: "Max",
+		FuncName: args.FuncName,
+		Args:     encoded,
 	})
 
-	r.Driver.RegisterRDD(newRDD)
-
-    tasks := newRDD.GetTasks()
-    
-    // logging del Job
-    jobID := rand.Intn(1000)
-    job := types.Job{
-        ID:     jobID,
-        RDD:    newRDD.ID,
-        Tasks:  tasks,
-        Status: "running",
+    partialResults, err := d.runJob(newRDD)
+    if err != nil {
+        return err
     }
-    d.RegisterJob(job)
-    d.SaveJobState(job.ID, "running")
-
-    partialResults := d.SendTasks(tasks)
     
     log.Printf("Partial results: %v\n", partialResults)
 	flat := []types.Row{}
@@ -187,86 +506,515 @@ func (d *Driver) Reduce(id int, reply *[]types.Row) error {
 		flat = append(flat, chunk...)
 	}
 
-    fn := utils.FuncRegistry["Max"].(func(types.Row, types.Row) types.Row)
-    result := utils.Reduce(flat, fn)
+    result, ok := utils.Reduce(flat, fn)
+    if !ok {
+        return fmt.Errorf("cannot reduce RDD %d: it has no rows", r.ID)
+    }
     log.Printf("Reduced result: %v\n", result)
 
-    d.SaveJobState(job.ID, "completed")
-    
-    *reply = []types.Row{result}
+    *reply = result
     return nil
 }
 
-func (d *Driver) Join(request types.JoinRequest, reply *int) error {
-    r1, exists1 := d.RDDRegistry[request.RddID1]
-    r2, exists2 := d.RDDRegistry[request.RddID2]
-    numPartitions := max(r1.NumPartitions, r2.NumPartitions)
-    
-    if !exists1 || !exists2 {
-        return fmt.Errorf("one or both RDDs not found")
-    }
+// ReduceByKey RPC method - combina las filas de cada key dentro de cada task
+// (map-side combine), redistribuye los parciales por hash de key y registra un
+// RDD que aplica la reducción final por key sobre las particiones destino
+func (d *Driver) ReduceByKey(args types.ReduceByKeyArgs, reply *int) error {
+	r, exists := d.RDDRegistry[args.RDDID]
+	if !exists {
+		return fmt.Errorf("RDD %d not found", args.RDDID)
+	}
+	if err := d.checkFunc(args.FuncName, types.ReduceByKeyOp); err != nil {
+		return err
+	}
+	if err := checkArgs(args.FuncName, args.Args); err != nil {
+		return err
+	}
+	encoded, err := utils.EncodeArgs(args.Args)
+	if err != nil {
+		return err
+	}
 
-    tasks1 := r1.GetTasks()
-    tasks2 := r2.GetTasks()
+	combine := types.Transformation{
+		Type:     types.ReduceByKeyOp,
+		FuncName: args.FuncName,
+		Args:     encoded,
+	}
 
-    results1 := d.SendTasks(tasks1)
-    results2 := d.SendTasks(tasks2)
-    
-    flat1 := []types.Row{}
-    flat2 := []types.Row{}
+	reduced, err := d.combineByKey(r, combine, combine)
+	if err != nil {
+		return err
+	}
+	*reply = reduced.ID
+	return nil
+}
 
-    for _, part := range results1 { flat1 = append(flat1, part...) }
-    for _, part := range results2 { flat2 = append(flat2, part...) }
+// CombineByKey RPC method - como ReduceByKey, pero el acumulador de cada key
+// puede tener otro tipo que las filas: se crea con CreateCombiner, se le agregan
+// filas con MergeValue y los parciales se combinan con MergeCombiners
+func (d *Driver) CombineByKey(args types.CombineByKeyArgs, reply *int) error {
+	r, exists := d.RDDRegistry[args.RDDID]
+	if !exists {
+		return fmt.Errorf("RDD %d not found", args.RDDID)
+	}
+	if err := d.checkFuncNoArgs(args.CreateCombiner, types.MapOp); err != nil {
+		return err
+	}
+	if err := d.checkFuncNoArgs(args.MergeValue, types.CombineByKeyOp); err != nil {
+		return err
+	}
+	if err := d.checkFuncNoArgs(args.MergeCombiners, types.ReduceByKeyOp); err != nil {
+		return err
+	}
 
-    leftParts  := utils.Shuffle(flat1, numPartitions)
-    rightParts := utils.Shuffle(flat2, numPartitions)
-    log.Printf("Join solicitado entre RDD %d y RDD %d\n", r1.ID, r2.ID)
+	combine := types.Transformation{
+		Type:     types.CombineByKeyOp,
+		FuncName: args.MergeValue,
+		Options:  map[string]interface{}{"createCombiner": args.CreateCombiner},
+	}
+	merge := types.Transformation{
+		Type:     types.ReduceByKeyOp,
+		FuncName: args.MergeCombiners,
+	}
 
-    joinedPartitions := make(map[int][]types.Row)
-    var mu sync.Mutex
-    var wg sync.WaitGroup
-    wg.Add(numPartitions)
+	combined, err := d.combineByKey(r, combine, merge)
+	if err != nil {
+		return err
+	}
+	*reply = combined.ID
+	return nil
+}
 
-    for partID := 0; partID < numPartitions; partID++ {
-        go func(partID int) {
-            defer wg.Done()
+// GroupByKey RPC method - redistribuye las filas por hash de key y registra un
+// RDD que reúne los valores de cada key en un Row{Key, []interface{}}
+func (d *Driver) GroupByKey(id int, reply *int) error {
+	r, exists := d.RDDRegistry[id]
+	if !exists {
+		return fmt.Errorf("RDD %d not found", id)
+	}
 
-            leftRows  := leftParts[partID]
-            rightRows := rightParts[partID]
+	// si r ya tiene un Partitioner las filas de cada key están juntas
+	shuffled := r
+	if r.Partitioner == nil {
+		p := utils.HashPartitioner{N: r.NumPartitions}
+		parts, err := d.partitionBy(r, p)
+		if err != nil {
+			return err
+		}
+		shuffled = d.materialize(parts, p.N)
+		shuffled.Partitioner = p
+	}
 
-            workerID := d.PartitionMap[partID]
-            workerAddr := d.Workers[workerID].Endpoint
+	grouped := d.transform(shuffled, types.Transformation{
+		Type: types.GroupByKeyOp,
+	})
 
-            task := types.TaskJoin{
-                ID:          newID(),
-                LeftRows:    leftRows,
-                RightRows:   rightRows,
-            }
- 
-            client, err := rpc.Dial("tcp", workerAddr)
-            if err != nil {
-                log.Printf("Join: worker %d offline: %v\n", workerID, err)
-                return
-            }
-            defer client.Close()
+	*reply = grouped.ID
+	return nil
+}
 
-            var reply types.TaskReply
-            err = client.Call("Worker.ExecuteJoin", task, &reply)
-            if err != nil {
-                log.Printf("Join: task %d failed on worker %d: %v\n", task.ID, workerID, err)
-                return
-            }
+// CoGroup RPC method - agrupa por key los valores de dos RDDs, cada fila del
+// resultado es Row{Key, []interface{}{valoresRDD1, valoresRDD2}}
+func (d *Driver) CoGroup(request types.CoGroupRequest, reply *int) error {
+	r1, exists1 := d.RDDRegistry[request.RddID1]
+	r2, exists2 := d.RDDRegistry[request.RddID2]
+	if !exists1 || !exists2 {
+		return fmt.Errorf("one or both RDDs not found")
+	}
+
+	log.Printf("CoGroup solicitado entre RDD %d y RDD %d\n", r1.ID, r2.ID)
+	grouped, err := d.coPartitionByKey(r1, r2, "Worker.ExecuteCoGroup", types.TaskJoin{})
+	if err != nil {
+		return err
+	}
 
-            mu.Lock()
-            joinedPartitions[partID] = reply.Data
-            mu.Unlock()
-        }(partID)
+	*reply = grouped.ID
+	return nil
+}
+
+// SortBy RPC method - redistribuye las filas en rangos de la key que devuelve
+// args.FuncName y registra un RDD que ordena cada partición en los workers. Al
+// recolectar las particiones en orden el resultado queda totalmente ordenado.
+func (d *Driver) SortBy(args types.SortArgs, reply *int) error {
+	r, exists := d.RDDRegistry[args.RDDID]
+	if !exists {
+		return fmt.Errorf("RDD %d not found", args.RDDID)
+	}
+	if err := d.checkFuncNoArgs(args.FuncName, types.SortOp); err != nil {
+		return err
+	}
+	keyFn, err := utils.FuncRegistry.KeyExtractor(args.FuncName, nil, nil)
+	if err != nil {
+		return err
+	}
+	return d.sort(r, args.FuncName, keyFn, args.Ascending, reply)
+}
+
+// SortByKey RPC method - igual que SortBy pero ordena por Row.Key
+func (d *Driver) SortByKey(args types.SortArgs, reply *int) error {
+	r, exists := d.RDDRegistry[args.RDDID]
+	if !exists {
+		return fmt.Errorf("RDD %d not found", args.RDDID)
+	}
+
+	return d.sort(r, "", utils.KeyOf, args.Ascending, reply)
+}
+
+func (d *Driver) sort(r *RDD, funcName string, keyFn func(types.Row) interface{}, ascending bool, reply *int) error {
+	ranged, p, err := d.rangeShuffle(r, keyFn, ascending, r.NumPartitions)
+	if err != nil {
+		return err
+	}
+	if funcName == "" {
+		// ordenado por Row.Key, las particiones quedan en rangos de key
+		ranged.Partitioner = p
+	}
+
+	sorted := d.transform(ranged, types.Transformation{
+		Type:     types.SortOp,
+		FuncName: funcName,
+		Options:  map[string]interface{}{"ascending": ascending},
+	})
+
+	*reply = sorted.ID
+	return nil
+}
+
+// PartitionBy RPC method - redistribuye las filas por key con el Partitioner
+// args.Partitioner ("hash", "range" o uno de utils.PartitionerRegistry). Los
+// joins y ReduceByKey posteriores sobre el resultado no redistribuyen.
+func (d *Driver) PartitionBy(args types.PartitionByArgs, reply *int) error {
+	r, exists := d.RDDRegistry[args.RDDID]
+	if !exists {
+		return fmt.Errorf("RDD %d not found", args.RDDID)
+	}
+	numPartitions := args.NumPartitions
+	if numPartitions <= 0 {
+		numPartitions = r.NumPartitions
+	}
+
+	if args.Partitioner == "range" {
+		ranged, p, err := d.rangeShuffle(r, utils.KeyOf, true, numPartitions)
+		if err != nil {
+			return err
+		}
+		ranged.Partitioner = p
+		*reply = ranged.ID
+		return nil
+	}
+
+	p, err := utils.NewPartitioner(args.Partitioner, numPartitions)
+	if err != nil {
+		return err
+	}
+	if r.Partitioner != nil && r.Partitioner.Compatible(p) {
+		*reply = r.ID
+		return nil
+	}
+
+	parts, err := d.partitionBy(r, p)
+	if err != nil {
+		return err
+	}
+	partitioned := d.materialize(parts, numPartitions)
+	partitioned.Partitioner = p
+
+	*reply = partitioned.ID
+	return nil
+}
+
+// Repartition RPC method - redistribuye todas las filas en args.NumPartitions
+// particiones nuevas de tamaño similar (shuffle completo)
+func (d *Driver) Repartition(args types.RepartitionArgs, reply *int) error {
+	r, exists := d.RDDRegistry[args.RDDID]
+	if !exists {
+		return fmt.Errorf("RDD %d not found", args.RDDID)
+	}
+	if args.NumPartitions <= 0 {
+		return fmt.Errorf("invalid number of partitions %d", args.NumPartitions)
+	}
+
+	results, err := d.runJob(r)
+	if err != nil {
+		return err
+	}
+
+	// round-robin, cada partición de origen empieza en un destino distinto
+	parts := make(map[int][]types.Row)
+	for i, rows := range results {
+		for j, row := range rows {
+			target := (i + j) % args.NumPartitions
+			parts[target] = append(parts[target], row)
+		}
+	}
+
+	repartitioned := d.materialize(parts, args.NumPartitions)
+	*reply = repartitioned.ID
+	return nil
+}
+
+// Coalesce RPC method - reduce la cantidad de particiones uniendo particiones
+// vecinas, sin redistribuir filas entre ellas. No evalúa r: cada task del RDD
+// resultante ejecuta las de las particiones que reúne. Si args.NumPartitions no
+// es menor que la cantidad actual devuelve el mismo RDD.
+func (d *Driver) Coalesce(args types.RepartitionArgs, reply *int) error {
+	r, exists := d.RDDRegistry[args.RDDID]
+	if !exists {
+		return fmt.Errorf("RDD %d not found", args.RDDID)
+	}
+	if args.NumPartitions <= 0 {
+		return fmt.Errorf("invalid number of partitions %d", args.NumPartitions)
+	}
+	if args.NumPartitions >= r.NumPartitions {
+		*reply = r.ID
+		return nil
+	}
+
+	// la partición destino j reúne las particiones [j*m/n, (j+1)*m/n). Recibe
+	// un ID propio, para no compartir PartitionMap ni PartitionCache con las
+	// de r, y se ejecuta en el worker de la primera partición que reúne
+	groups := make([][]int, args.NumPartitions)
+	for i := 0; i < r.NumPartitions; i++ {
+		target := i * args.NumPartitions / r.NumPartitions
+		groups[target] = append(groups[target], i)
+	}
+	partitions := make([]int, args.NumPartitions)
+	for j, group := range groups {
+		partitionID := d.nextPartitionID
+		d.nextPartitionID++
+		d.PartitionMap[partitionID] = d.PartitionMap[r.Partitions[group[0]]]
+		partitions[j] = partitionID
+	}
+
+	coalesced := &RDD{
+		ID:            newID(),
+		Parent:        r,
+		NumPartitions: args.NumPartitions,
+		Partitions:    partitions,
+		Coalesced:     groups,
+		Driver:        d,
+	}
+	d.RegisterRDD(coalesced)
+
+	*reply = coalesced.ID
+	return nil
+}
+
+// Union RPC method - registra un RDD cuyas particiones son las de RddID1
+// seguidas por las de RddID2, sin mover datos
+func (d *Driver) Union(request types.SetOpRequest, reply *int) error {
+	r1, exists1 := d.RDDRegistry[request.RddID1]
+	r2, exists2 := d.RDDRegistry[request.RddID2]
+	if !exists1 || !exists2 {
+		return fmt.Errorf("one or both RDDs not found")
+	}
+
+	union := &RDD{
+		ID:            newID(),
+		NumPartitions: r1.NumPartitions + r2.NumPartitions,
+		Partitions:    append(append([]int{}, r1.Partitions...), r2.Partitions...),
+		Sources:       []*RDD{r1, r2},
+		Driver:        d,
+	}
+	d.RegisterRDD(union)
+
+	*reply = union.ID
+	return nil
+}
+
+// Distinct RPC method - redistribuye las filas por hash de la fila completa y
+// registra un RDD que elimina los duplicados de cada partición
+func (d *Driver) Distinct(id int, reply *int) error {
+	r, exists := d.RDDRegistry[id]
+	if !exists {
+		return fmt.Errorf("RDD %d not found", id)
+	}
+
+	numPartitions := r.NumPartitions
+	results, err := d.runJob(r)
+	if err != nil {
+		return err
+	}
+	shuffled := d.materialize(utils.ShuffleRows(flatten(results), numPartitions), numPartitions)
+
+	distinct := d.transform(shuffled, types.Transformation{
+		Type: types.DistinctOp,
+	})
+
+	*reply = distinct.ID
+	return nil
+}
+
+// Intersection RPC method - filas distintas presentes en ambos RDDs
+func (d *Driver) Intersection(request types.SetOpRequest, reply *int) error {
+	return d.setOp(request, "Worker.ExecuteIntersection", reply)
+}
+
+// Subtract RPC method - filas de RddID1 que no están en RddID2
+func (d *Driver) Subtract(request types.SetOpRequest, reply *int) error {
+	return d.setOp(request, "Worker.ExecuteSubtract", reply)
+}
+
+func (d *Driver) setOp(request types.SetOpRequest, method string, reply *int) error {
+	r1, exists1 := d.RDDRegistry[request.RddID1]
+	r2, exists2 := d.RDDRegistry[request.RddID2]
+	if !exists1 || !exists2 {
+		return fmt.Errorf("one or both RDDs not found")
+	}
+
+	result, err := d.coPartition(r1, r2, utils.ShuffleRows, method, types.TaskJoin{})
+	if err != nil {
+		return err
+	}
+
+	*reply = result.ID
+	return nil
+}
+
+// Sample RPC method - registra un RDD hijo con una muestra de las filas, que
+// cada worker toma con una semilla derivada de args.Seed y de la partición
+func (d *Driver) Sample(args types.SampleArgs, reply *int) error {
+	r, exists := d.RDDRegistry[args.RDDID]
+	if !exists {
+		return fmt.Errorf("RDD %d not found", args.RDDID)
+	}
+	if args.Fraction < 0 || (!args.WithReplacement && args.Fraction > 1) {
+		return fmt.Errorf("invalid sample fraction %v", args.Fraction)
+	}
+
+	sampled := d.transform(r, types.Transformation{
+		Type: types.SampleOp,
+		Options: map[string]interface{}{
+			"withReplacement": args.WithReplacement,
+			"lower":           0.0,
+			"upper":           args.Fraction,
+			"seed":            args.Seed,
+		},
+	})
+
+	*reply = sampled.ID
+	return nil
+}
+
+// RandomSplit RPC method - divide el RDD en un RDD por peso. Todas las partes
+// usan la misma semilla y rangos disjuntos de [0, 1), así cada fila cae en
+// exactamente una de ellas.
+func (d *Driver) RandomSplit(args types.RandomSplitArgs, reply *[]int) error {
+	r, exists := d.RDDRegistry[args.RDDID]
+	if !exists {
+		return fmt.Errorf("RDD %d not found", args.RDDID)
+	}
+
+	total := 0.0
+	for _, w := range args.Weights {
+		if w < 0 {
+			return fmt.Errorf("invalid split weight %v", w)
+		}
+		total += w
+	}
+	if total == 0 {
+		return fmt.Errorf("split weights must add up to more than zero")
+	}
+
+	ids := []int{}
+	lower := 0.0
+	for i, w := range args.Weights {
+		upper := lower + w/total
+		if i == len(args.Weights)-1 {
+			upper = 1.0
+		}
+
+		split := d.transform(r, types.Transformation{
+			Type: types.SampleOp,
+			Options: map[string]interface{}{
+				"lower": lower,
+				"upper": upper,
+				"seed":  args.Seed,
+			},
+		})
+		ids = append(ids, split.ID)
+		lower = upper
+	}
+
+	*reply = ids
+	return nil
+}
+
+// SampleByKey RPC method - muestreo estratificado, cada fila se conserva con la
+// fracción de su key
+func (d *Driver) SampleByKey(args types.SampleByKeyArgs, reply *int) error {
+	r, exists := d.RDDRegistry[args.RDDID]
+	if !exists {
+		return fmt.Errorf("RDD %d not found", args.RDDID)
+	}
+
+	fractions := make(map[string]interface{}, len(args.Fractions))
+	for key, fraction := range args.Fractions {
+		if fraction < 0 || (!args.WithReplacement && fraction > 1) {
+			return fmt.Errorf("invalid sample fraction %v for key %s", fraction, key)
+		}
+		fractions[key] = fraction
+	}
+
+	sampled := d.transform(r, types.Transformation{
+		Type: types.SampleOp,
+		Options: map[string]interface{}{
+			"withReplacement": args.WithReplacement,
+			"fractions":       fractions,
+			"seed":            args.Seed,
+		},
+	})
+
+	*reply = sampled.ID
+	return nil
+}
+
+// Join RPC method - si un lado es chico (ver chooseBroadcast) lo difunde a los
+// workers y hace el join sobre las particiones del otro sin shuffle. Si no,
+// ubica ambos RDDs con un Partitioner común (sin redistribuir el lado que ya lo
+// tiene), ejecuta el join de cada par de particiones en los workers y registra
+// el resultado como un RDD
+func (d *Driver) Join(request types.JoinRequest, reply *int) error {
+    r1, exists1 := d.RDDRegistry[request.RddID1]
+    r2, exists2 := d.RDDRegistry[request.RddID2]
+    if !exists1 || !exists2 {
+        return fmt.Errorf("one or both RDDs not found")
     }
 
-    wg.Wait()
+    log.Printf("Join solicitado entre RDD %d y RDD %d\n", r1.ID, r2.ID)
+    if broadcastLeft, ok := d.chooseBroadcast(r1, r2, request.JoinType); ok {
+        joined := d.broadcastJoin(r1, r2, broadcastLeft, request)
+        *reply = joined.ID
+        return nil
+    }
 
-    flatJoined := utils.FlattenPartition(joinedPartitions)
-    utils.WriteCSV("testing/result.csv", flatJoined) 
+    joined, err := d.coPartitionByKey(r1, r2, "Worker.ExecuteJoin", types.TaskJoin{
+        JoinType:  request.JoinType,
+        Collision: request.Collision,
+    })
+    if err != nil {
+        return err
+    }
 
+    *reply = joined.ID
     return nil
 }
+
+// SaveCSV RPC method - evalúa el RDD y escribe sus filas en un archivo CSV
+func (d *Driver) SaveCSV(args types.SaveArgs, reply *bool) error {
+    r, exists := d.RDDRegistry[args.RDDID]
+    if !exists {
+        return fmt.Errorf("RDD %d not found", args.RDDID)
+    }
+
+    results, err := d.runJob(r)
+    if err != nil {
+        return err
+    }
+    if err := utils.WriteCSV(args.FilePath, flatten(results)); err != nil {
+        return err
+    }
+
+    *reply = true
+    return nil
+}
\ No newline at end of file
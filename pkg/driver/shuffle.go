@@ -0,0 +1,238 @@
+package driver
+
+import (
+	"Go-Mini-Spark/pkg/types"
+	"Go-Mini-Spark/pkg/utils"
+	"fmt"
+	"log"
+	"net/rpc"
+	"sync"
+)
+
+// sampleSizePerPartition es la cantidad de keys que se muestrean de cada
+// partición para calcular los rangos de SortBy
+const sampleSizePerPartition = 20
+
+// flatten concatena los resultados por partición devueltos por SendTasks
+func flatten(results [][]types.Row) []types.Row {
+	flat := []types.Row{}
+	for _, chunk := range results {
+		flat = append(flat, chunk...)
+	}
+	return flat
+}
+
+// materialize registra un RDD raíz con numPartitions particiones y guarda en
+// PartitionCache las filas de cada una, típicamente la salida de un shuffle.
+func (d *Driver) materialize(parts map[int][]types.Row, numPartitions int) *RDD {
+	rdd := &RDD{
+		ID:              newID(),
+		Parent:          nil,
+		NumPartitions:   numPartitions,
+		Transformations: []types.Transformation{},
+		Driver:          d,
+	}
+	d.RegisterRDD(rdd)
+
+	for i, partitionID := range rdd.Partitions {
+		rows := parts[i]
+		if rows == nil {
+			rows = []types.Row{}
+		}
+		d.Cache.Put(partitionID, rows)
+	}
+
+	log.Printf("Materialized RDD %d with %d partitions\n", rdd.ID, numPartitions)
+	return rdd
+}
+
+// combineByKey ejecuta combine en cada partición de r (map-side combine),
+// redistribuye los parciales por hash de key y registra un RDD que aplica merge
+// sobre las particiones destino cuando se evalúa. Si r ya tiene un Partitioner,
+// las filas de cada key están juntas y combine produce el resultado final.
+func (d *Driver) combineByKey(r *RDD, combine, merge types.Transformation) (*RDD, error) {
+	if r.Partitioner != nil {
+		return d.transform(r, combine), nil
+	}
+
+	// shuffle de los parciales hacia las particiones destino
+	p := utils.HashPartitioner{N: r.NumPartitions}
+	parts, err := d.partitionBy(d.transform(r, combine), p)
+	if err != nil {
+		return nil, err
+	}
+	shuffled := d.materialize(parts, p.N)
+	shuffled.Partitioner = p
+
+	// reducción final por key, se ejecuta en los workers al evaluar el RDD
+	return d.transform(shuffled, merge), nil
+}
+
+// coPartition evalúa left y right, redistribuye ambos lados con shuffle (por
+// hash de key o de la fila completa) y ejecuta method sobre cada par de
+// particiones (ver zipPartitions)
+func (d *Driver) coPartition(left, right *RDD, shuffle func([]types.Row, int) map[int][]types.Row, method string, template types.TaskJoin) (*RDD, error) {
+	numPartitions := max(left.NumPartitions, right.NumPartitions)
+
+	leftResults, err := d.runJob(left)
+	if err != nil {
+		return nil, err
+	}
+	rightResults, err := d.runJob(right)
+	if err != nil {
+		return nil, err
+	}
+
+	leftParts := shuffle(flatten(leftResults), numPartitions)
+	rightParts := shuffle(flatten(rightResults), numPartitions)
+	return d.zipPartitions(leftParts, rightParts, numPartitions, method, template)
+}
+
+// coPartitionByKey es coPartition por key: ambos lados se ubican con el
+// Partitioner de joinPartitioner. Las filas de un lado que ya está particionado
+// de forma compatible conservan su partición, aunque igual pasan por el driver
+// (ver partitionBy). El resultado conserva ese Partitioner.
+func (d *Driver) coPartitionByKey(left, right *RDD, method string, template types.TaskJoin) (*RDD, error) {
+	p := joinPartitioner(left, right)
+
+	leftParts, err := d.partitionBy(left, p)
+	if err != nil {
+		return nil, err
+	}
+	rightParts, err := d.partitionBy(right, p)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := d.zipPartitions(leftParts, rightParts, p.NumPartitions(), method, template)
+	if err != nil {
+		return nil, err
+	}
+	out.Partitioner = p
+	return out, nil
+}
+
+// joinPartitioner elige el Partitioner de un join: el de alguno de los lados si
+// lo tiene, para no reagrupar sus filas, o hash sobre la mayor cantidad de
+// particiones
+func joinPartitioner(left, right *RDD) utils.Partitioner {
+	if left.Partitioner != nil {
+		return left.Partitioner
+	}
+	if right.Partitioner != nil {
+		return right.Partitioner
+	}
+	return utils.HashPartitioner{N: max(left.NumPartitions, right.NumPartitions)}
+}
+
+// partitionBy evalúa r y agrupa sus filas según p. Si r ya está particionado de
+// forma compatible con p, no se recalcula la partición de cada fila y los
+// resultados de cada task se usan tal cual; las filas igual se recolectan en el
+// driver.
+func (d *Driver) partitionBy(r *RDD, p utils.Partitioner) (map[int][]types.Row, error) {
+	results, err := d.runJob(r)
+	if err != nil {
+		return nil, err
+	}
+	if r.Partitioner == nil || !r.Partitioner.Compatible(p) {
+		return utils.PartitionRows(flatten(results), p), nil
+	}
+
+	log.Printf("RDD %d already partitioned, keeping its partitions\n", r.ID)
+	parts := make(map[int][]types.Row, len(results))
+	for i, rows := range results {
+		parts[i] = rows
+	}
+	return parts, nil
+}
+
+// zipPartitions ejecuta method (un RPC del worker que recibe types.TaskJoin)
+// sobre cada par de particiones leftParts[i] y rightParts[i], en el worker
+// asignado a la partición destino. template aporta los campos de la task que no
+// son filas. El resultado se guarda como un nuevo RDD.
+func (d *Driver) zipPartitions(leftParts, rightParts map[int][]types.Row, numPartitions int, method string, template types.TaskJoin) (*RDD, error) {
+	out := d.materialize(nil, numPartitions)
+
+	var mu sync.Mutex
+	var errs []error
+	var wg sync.WaitGroup
+	wg.Add(numPartitions)
+
+	for i, partitionID := range out.Partitions {
+		go func(i, partitionID int) {
+			defer wg.Done()
+
+			workerID := d.PartitionMap[partitionID]
+			task := template
+			task.ID = newID()
+			task.LeftRows = leftParts[i]
+			task.RightRows = rightParts[i]
+
+			var reply types.TaskReply
+			err := callWorker(d.Workers[workerID].Endpoint, method, task, &reply)
+			if err != nil {
+				log.Printf("%s: task %d failed on worker %d: %v\n", method, task.ID, workerID, err)
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+				return
+			}
+
+			d.Cache.Put(partitionID, reply.Data)
+		}(i, partitionID)
+	}
+
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("%s failed on %d of %d partitions: %w", method, len(errs), numPartitions, errs[0])
+	}
+	return out, nil
+}
+
+// callWorker abre una conexión con el worker, ejecuta method y la cierra
+func callWorker(endpoint, method string, args interface{}, reply interface{}) error {
+	client, err := rpc.Dial("tcp", endpoint)
+	if err != nil {
+		return fmt.Errorf("worker %s unreachable: %w", endpoint, err)
+	}
+	defer client.Close()
+
+	return client.Call(method, args, reply)
+}
+
+// rangeShuffle evalúa r y redistribuye sus filas en numPartitions rangos
+// contiguos de la key que devuelve keyFn, y retorna también el RangePartitioner
+// usado. Los límites se calculan a partir de una muestra de cada partición; con
+// ascending en false los rangos se asignan en orden inverso.
+func (d *Driver) rangeShuffle(r *RDD, keyFn func(types.Row) interface{}, ascending bool, numPartitions int) (*RDD, utils.Partitioner, error) {
+	results, err := d.runJob(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// muestreo de keys de cada partición
+	sample := []interface{}{}
+	for _, rows := range results {
+		step := max(1, len(rows)/sampleSizePerPartition)
+		for i := 0; i < len(rows); i += step {
+			sample = append(sample, keyFn(rows[i]))
+		}
+	}
+	p := utils.RangePartitioner{
+		N:         numPartitions,
+		Bounds:    utils.RangeBounds(sample, numPartitions),
+		Ascending: ascending,
+	}
+	log.Printf("Range bounds for RDD %d: %v\n", r.ID, p.Bounds)
+
+	parts := make(map[int][]types.Row)
+	for _, rows := range results {
+		for _, row := range rows {
+			partition := p.Partition(keyFn(row))
+			parts[partition] = append(parts[partition], row)
+		}
+	}
+
+	return d.materialize(parts, numPartitions), p, nil
+}
@@ -0,0 +1,296 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// builtin is a function callable from expressions. Most builtins receive their
+// evaluated arguments; lazy ones (if, coalesce) evaluate only what they need.
+type builtin struct {
+	minArgs int
+	maxArgs int // -1 for variadic
+	impl    func(args []interface{}) (interface{}, error)
+	lazy    func(row env, args []node) (interface{}, error)
+}
+
+func (b *builtin) arity() string {
+	switch {
+	case b.maxArgs < 0:
+		return fmt.Sprintf("at least %d arguments", b.minArgs)
+	case b.minArgs == b.maxArgs && b.minArgs == 1:
+		return "1 argument"
+	case b.minArgs == b.maxArgs:
+		return fmt.Sprintf("%d arguments", b.minArgs)
+	}
+	return fmt.Sprintf("%d to %d arguments", b.minArgs, b.maxArgs)
+}
+
+// strings1 adapts a string function of one argument; null stays null.
+func strings1(fn func(s string) interface{}) *builtin {
+	return &builtin{minArgs: 1, maxArgs: 1, impl: func(args []interface{}) (interface{}, error) {
+		if args[0] == nil {
+			return nil, nil
+		}
+		return fn(toString(args[0])), nil
+	}}
+}
+
+// strings2 adapts a string function of two arguments; null stays null.
+func strings2(fn func(s, t string) interface{}) *builtin {
+	return &builtin{minArgs: 2, maxArgs: 2, impl: func(args []interface{}) (interface{}, error) {
+		if args[0] == nil || args[1] == nil {
+			return nil, nil
+		}
+		return fn(toString(args[0]), toString(args[1])), nil
+	}}
+}
+
+// math1 adapts a numeric function of one argument; null stays null.
+func math1(fn func(x float64) float64) *builtin {
+	return &builtin{minArgs: 1, maxArgs: 1, impl: func(args []interface{}) (interface{}, error) {
+		if args[0] == nil {
+			return nil, nil
+		}
+		x, _, ok := toNumber(args[0])
+		if !ok {
+			return nil, fmt.Errorf("expected a number, got %s", typeName(args[0]))
+		}
+		return fn(x), nil
+	}}
+}
+
+// regexCache keeps the compiled patterns of matches.
+var regexCache sync.Map
+
+// builtins are the functions expressions can call.
+var builtins = map[string]*builtin{
+	// strings
+	"upper": strings1(func(s string) interface{} { return strings.ToUpper(s) }),
+	"lower": strings1(func(s string) interface{} { return strings.ToLower(s) }),
+	"trim":  strings1(func(s string) interface{} { return strings.TrimSpace(s) }),
+	"contains": strings2(func(s, sub string) interface{} {
+		return strings.Contains(s, sub)
+	}),
+	"startsWith": strings2(func(s, prefix string) interface{} {
+		return strings.HasPrefix(s, prefix)
+	}),
+	"endsWith": strings2(func(s, suffix string) interface{} {
+		return strings.HasSuffix(s, suffix)
+	}),
+	"split": strings2(func(s, sep string) interface{} {
+		parts := strings.Split(s, sep)
+		list := make([]interface{}, len(parts))
+		for i, part := range parts {
+			list[i] = part
+		}
+		return list
+	}),
+	"replace": {minArgs: 3, maxArgs: 3, impl: func(args []interface{}) (interface{}, error) {
+		if args[0] == nil {
+			return nil, nil
+		}
+		return strings.ReplaceAll(toString(args[0]), toString(args[1]), toString(args[2])), nil
+	}},
+	"substr": {minArgs: 2, maxArgs: 3, impl: substr},
+	"concat": {minArgs: 1, maxArgs: -1, impl: func(args []interface{}) (interface{}, error) {
+		var sb strings.Builder
+		for _, arg := range args {
+			sb.WriteString(toString(arg))
+		}
+		return sb.String(), nil
+	}},
+	"matches": {minArgs: 2, maxArgs: 2, impl: matches},
+	"len":     {minArgs: 1, maxArgs: 1, impl: length},
+
+	// numbers
+	"abs":   math1(math.Abs),
+	"floor": math1(math.Floor),
+	"ceil":  math1(math.Ceil),
+	"sqrt":  math1(math.Sqrt),
+	"round": {minArgs: 1, maxArgs: 2, impl: round},
+	"min":   {minArgs: 1, maxArgs: -1, impl: extreme(-1)},
+	"max":   {minArgs: 1, maxArgs: -1, impl: extreme(1)},
+
+	// conversions
+	"int":   {minArgs: 1, maxArgs: 1, impl: toIntValue},
+	"float": {minArgs: 1, maxArgs: 1, impl: toFloatValue},
+	"str": {minArgs: 1, maxArgs: 1, impl: func(args []interface{}) (interface{}, error) {
+		if args[0] == nil {
+			return nil, nil
+		}
+		return toString(args[0]), nil
+	}},
+	"isNull": {minArgs: 1, maxArgs: 1, impl: func(args []interface{}) (interface{}, error) {
+		return args[0] == nil, nil
+	}},
+
+	// conditionals
+	"if":       {minArgs: 3, maxArgs: 3, lazy: ifThenElse},
+	"coalesce": {minArgs: 1, maxArgs: -1, lazy: coalesce},
+}
+
+// substr(s, start[, length]) counts in characters; out-of-range bounds are
+// clamped.
+func substr(args []interface{}) (interface{}, error) {
+	if args[0] == nil {
+		return nil, nil
+	}
+	runes := []rune(toString(args[0]))
+	start, ok := toInt(args[1])
+	if !ok {
+		return nil, fmt.Errorf("start must be an integer, got %s", typeName(args[1]))
+	}
+	start = max(0, min(start, len(runes)))
+
+	end := len(runes)
+	if len(args) == 3 {
+		n, ok := toInt(args[2])
+		if !ok {
+			return nil, fmt.Errorf("length must be an integer, got %s", typeName(args[2]))
+		}
+		end = max(start, min(start+n, len(runes)))
+	}
+	return string(runes[start:end]), nil
+}
+
+func matches(args []interface{}) (interface{}, error) {
+	if args[0] == nil || args[1] == nil {
+		return nil, nil
+	}
+	pattern := toString(args[1])
+	re, cached := regexCache.Load(pattern)
+	if !cached {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		re, _ = regexCache.LoadOrStore(pattern, compiled)
+	}
+	return re.(*regexp.Regexp).MatchString(toString(args[0])), nil
+}
+
+func length(args []interface{}) (interface{}, error) {
+	switch v := args[0].(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return utf8.RuneCountInString(v), nil
+	case []interface{}:
+		return len(v), nil
+	case []string:
+		return len(v), nil
+	case map[string]interface{}:
+		return len(v), nil
+	case map[string]string:
+		return len(v), nil
+	case map[string]float64:
+		return len(v), nil
+	}
+	return nil, fmt.Errorf("cannot take the length of %s", typeName(args[0]))
+}
+
+// round(x[, digits]) rounds half away from zero.
+func round(args []interface{}) (interface{}, error) {
+	if args[0] == nil {
+		return nil, nil
+	}
+	x, _, ok := toNumber(args[0])
+	if !ok {
+		return nil, fmt.Errorf("expected a number, got %s", typeName(args[0]))
+	}
+	digits := 0
+	if len(args) == 2 {
+		if digits, ok = toInt(args[1]); !ok {
+			return nil, fmt.Errorf("digits must be an integer, got %s", typeName(args[1]))
+		}
+	}
+	scale := math.Pow(10, float64(digits))
+	return math.Round(x*scale) / scale, nil
+}
+
+// extreme returns min (sign -1) or max (sign 1) of its arguments, ignoring
+// nulls.
+func extreme(sign int) func(args []interface{}) (interface{}, error) {
+	return func(args []interface{}) (interface{}, error) {
+		var best interface{}
+		for _, arg := range args {
+			if arg == nil {
+				continue
+			}
+			if best == nil {
+				best = arg
+				continue
+			}
+			greater, err := compare(">", arg, best)
+			if err != nil {
+				return nil, err
+			}
+			if greater.(bool) == (sign > 0) && !equal(arg, best) {
+				best = arg
+			}
+		}
+		return best, nil
+	}
+}
+
+// toIntValue truncates numbers and parses numeric strings.
+func toIntValue(args []interface{}) (interface{}, error) {
+	if args[0] == nil {
+		return nil, nil
+	}
+	if b, ok := args[0].(bool); ok {
+		if b {
+			return 1, nil
+		}
+		return 0, nil
+	}
+	x, _, ok := toNumber(args[0])
+	if !ok {
+		return nil, fmt.Errorf("cannot convert %q to an integer", toString(args[0]))
+	}
+	return int(x), nil
+}
+
+func toFloatValue(args []interface{}) (interface{}, error) {
+	if args[0] == nil {
+		return nil, nil
+	}
+	x, _, ok := toNumber(args[0])
+	if !ok {
+		return nil, fmt.Errorf("cannot convert %q to a number", toString(args[0]))
+	}
+	return x, nil
+}
+
+// ifThenElse evaluates only the branch selected by the condition, so
+// if(value.qty == 0, 0, value.price / value.qty) does not divide by zero.
+func ifThenElse(row env, args []node) (interface{}, error) {
+	v, err := args[0].eval(row)
+	if err != nil {
+		return nil, err
+	}
+	cond, err := truth(v)
+	if err != nil {
+		return nil, fmt.Errorf("if: %w", err)
+	}
+	if cond {
+		return args[1].eval(row)
+	}
+	return args[2].eval(row)
+}
+
+// coalesce returns the first argument that is not null.
+func coalesce(row env, args []node) (interface{}, error) {
+	for _, arg := range args {
+		v, err := arg.eval(row)
+		if err != nil || v != nil {
+			return v, err
+		}
+	}
+	return nil, nil
+}
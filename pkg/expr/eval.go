@@ -0,0 +1,439 @@
+// Package expr parses and evaluates small expressions over types.Row, such as
+// value.price * value.qty > 100 or upper(value.name), so transformations can
+// be written without adding a function to the registry.
+//
+// An expression references the row through key and value. Fields of map
+// values (like the rows ReadCSV produces) are read with value.field or
+// value["field name"]; a missing field is null. Strings that look like numbers
+// are treated as numbers by arithmetic and comparisons, since CSV fields are
+// always strings. Arithmetic on null yields null and comparisons against null
+// are false.
+package expr
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"Go-Mini-Spark/pkg/types"
+)
+
+// Expr is a parsed expression.
+type Expr struct {
+	src  string
+	root node
+}
+
+// env is the row an expression is evaluated against.
+type env = types.Row
+
+// Parse parses src. Syntax errors, unknown identifiers, unknown functions and
+// wrong argument counts are reported here rather than at evaluation time.
+func Parse(src string) (*Expr, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, fmt.Errorf("expr %q: %w", src, err)
+	}
+	if tokens[0].kind == tokEOF {
+		return nil, fmt.Errorf("expr %q: empty expression", src)
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err == nil && p.peek().kind != tokEOF {
+		err = p.unexpected()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("expr %q: %w", src, err)
+	}
+	return &Expr{src: src, root: root}, nil
+}
+
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Eval evaluates the expression against row.
+func (e *Expr) Eval(row types.Row) (interface{}, error) {
+	v, err := e.root.eval(row)
+	if err != nil {
+		return nil, fmt.Errorf("expr %q: %w", e.src, err)
+	}
+	return v, nil
+}
+
+// EvalBool evaluates a predicate. null counts as false; any other non-boolean
+// result is an error.
+func (e *Expr) EvalBool(row types.Row) (bool, error) {
+	v, err := e.Eval(row)
+	if err != nil {
+		return false, err
+	}
+	b, err := truth(v)
+	if err != nil {
+		return false, fmt.Errorf("expr %q: %w", e.src, err)
+	}
+	return b, nil
+}
+
+func (n *literal) eval(row env) (interface{}, error) {
+	return n.value, nil
+}
+
+func (n *variable) eval(row env) (interface{}, error) {
+	if n.name == "key" {
+		return row.Key, nil
+	}
+	return row.Value, nil
+}
+
+func (n *field) eval(row env) (interface{}, error) {
+	target, err := n.target.eval(row)
+	if err != nil || target == nil {
+		return nil, err
+	}
+	return member(target, n.name)
+}
+
+func (n *index) eval(row env) (interface{}, error) {
+	target, err := n.target.eval(row)
+	if err != nil || target == nil {
+		return nil, err
+	}
+	idx, err := n.index.eval(row)
+	if err != nil || idx == nil {
+		return nil, err
+	}
+
+	switch t := target.(type) {
+	case []interface{}:
+		i, ok := toInt(idx)
+		if !ok {
+			return nil, fmt.Errorf("list index must be an integer, got %s", typeName(idx))
+		}
+		if i < 0 {
+			i += len(t)
+		}
+		if i < 0 || i >= len(t) {
+			return nil, nil
+		}
+		return t[i], nil
+	case []string:
+		i, ok := toInt(idx)
+		if !ok {
+			return nil, fmt.Errorf("list index must be an integer, got %s", typeName(idx))
+		}
+		if i < 0 {
+			i += len(t)
+		}
+		if i < 0 || i >= len(t) {
+			return nil, nil
+		}
+		return t[i], nil
+	}
+	return member(target, toString(idx))
+}
+
+// member reads the field name of a map value.
+func member(target interface{}, name string) (interface{}, error) {
+	switch m := target.(type) {
+	case map[string]interface{}:
+		return m[name], nil
+	case map[string]string:
+		if v, ok := m[name]; ok {
+			return v, nil
+		}
+		return nil, nil
+	case map[string]float64:
+		if v, ok := m[name]; ok {
+			return v, nil
+		}
+		return nil, nil
+	}
+	return nil, fmt.Errorf("cannot read field '%s' of %s", name, typeName(target))
+}
+
+func (n *unary) eval(row env) (interface{}, error) {
+	v, err := n.operand.eval(row)
+	if err != nil || v == nil {
+		return nil, err
+	}
+
+	if n.op == "!" {
+		b, err := truth(v)
+		if err != nil {
+			return nil, err
+		}
+		return !b, nil
+	}
+
+	num, isInt, ok := toNumber(v)
+	if !ok {
+		return nil, fmt.Errorf("cannot negate %s", typeName(v))
+	}
+	if isInt {
+		return -int(num), nil
+	}
+	return -num, nil
+}
+
+func (n *binary) eval(row env) (interface{}, error) {
+	left, err := n.left.eval(row)
+	if err != nil {
+		return nil, err
+	}
+
+	// && and || short-circuit
+	switch n.op {
+	case "&&", "||":
+		l, err := truth(left)
+		if err != nil {
+			return nil, err
+		}
+		if (n.op == "&&" && !l) || (n.op == "||" && l) {
+			return l, nil
+		}
+		right, err := n.right.eval(row)
+		if err != nil {
+			return nil, err
+		}
+		return truth(right)
+	}
+
+	right, err := n.right.eval(row)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==", "!=":
+		eq := equal(left, right)
+		if n.op == "!=" {
+			return !eq, nil
+		}
+		return eq, nil
+	case "<", "<=", ">", ">=":
+		return compare(n.op, left, right)
+	}
+	return arithmetic(n.op, left, right)
+}
+
+func (n *call) eval(row env) (interface{}, error) {
+	if n.fn.lazy != nil {
+		return n.fn.lazy(row, n.args)
+	}
+
+	args := make([]interface{}, len(n.args))
+	for i, arg := range n.args {
+		v, err := arg.eval(row)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	v, err := n.fn.impl(args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", n.name, err)
+	}
+	return v, nil
+}
+
+func (n *object) eval(row env) (interface{}, error) {
+	m := make(map[string]interface{}, len(n.keys))
+	for i, key := range n.keys {
+		v, err := n.values[i].eval(row)
+		if err != nil {
+			return nil, err
+		}
+		m[key] = v
+	}
+	return m, nil
+}
+
+// arithmetic applies + - * / %. Integers stay integers except for /, and +
+// concatenates when either side is a non-numeric string.
+func arithmetic(op string, left, right interface{}) (interface{}, error) {
+	if left == nil || right == nil {
+		return nil, nil
+	}
+
+	l, lInt, lok := toNumber(left)
+	r, rInt, rok := toNumber(right)
+	if !lok || !rok {
+		_, lStr := left.(string)
+		_, rStr := right.(string)
+		if op == "+" && (lStr || rStr) {
+			return toString(left) + toString(right), nil
+		}
+		return nil, fmt.Errorf("cannot apply '%s' to %s and %s", op, typeName(left), typeName(right))
+	}
+
+	if lInt && rInt && op != "/" {
+		a, b := int(l), int(r)
+		switch op {
+		case "+":
+			return a + b, nil
+		case "-":
+			return a - b, nil
+		case "*":
+			return a * b, nil
+		case "%":
+			if b == 0 {
+				return nil, fmt.Errorf("modulo by zero")
+			}
+			return a % b, nil
+		}
+	}
+
+	switch op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		if r == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return l / r, nil
+	case "%":
+		if r == 0 {
+			return nil, fmt.Errorf("modulo by zero")
+		}
+		return math.Mod(l, r), nil
+	}
+	return nil, fmt.Errorf("unknown operator '%s'", op)
+}
+
+// equal compares numerically when both sides are numbers (or numeric strings)
+// and by value otherwise.
+func equal(left, right interface{}) bool {
+	if left == nil || right == nil {
+		return left == nil && right == nil
+	}
+	if l, _, lok := toNumber(left); lok {
+		if r, _, rok := toNumber(right); rok {
+			return l == r
+		}
+	}
+	if lb, ok := left.(bool); ok {
+		rb, ok := right.(bool)
+		return ok && lb == rb
+	}
+	return toString(left) == toString(right)
+}
+
+func compare(op string, left, right interface{}) (interface{}, error) {
+	if left == nil || right == nil {
+		return false, nil
+	}
+
+	var c int
+	l, _, lok := toNumber(left)
+	r, _, rok := toNumber(right)
+	ls, lStr := left.(string)
+	rs, rStr := right.(string)
+	switch {
+	case lok && rok:
+		c = sortOrder(l < r, l > r)
+	case lStr && rStr:
+		c = sortOrder(ls < rs, ls > rs)
+	default:
+		return nil, fmt.Errorf("cannot compare %s and %s", typeName(left), typeName(right))
+	}
+
+	switch op {
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	}
+	return c >= 0, nil
+}
+
+func sortOrder(less, greater bool) int {
+	if less {
+		return -1
+	}
+	if greater {
+		return 1
+	}
+	return 0
+}
+
+// truth converts a boolean operand; null counts as false.
+func truth(v interface{}) (bool, error) {
+	switch b := v.(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return b, nil
+	}
+	return false, fmt.Errorf("expected a boolean, got %s", typeName(v))
+}
+
+// toNumber converts v to a float64, reporting whether it is an integer. Strings
+// are parsed, so CSV fields can be used in arithmetic directly.
+func toNumber(v interface{}) (num float64, isInt bool, ok bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true, true
+	case int32:
+		return float64(n), true, true
+	case int64:
+		return float64(n), true, true
+	case float32:
+		return float64(n), false, true
+	case float64:
+		return n, false, true
+	case string:
+		if i, err := strconv.Atoi(n); err == nil {
+			return float64(i), true, true
+		}
+		if f, err := strconv.ParseFloat(n, 64); err == nil {
+			return f, false, true
+		}
+	}
+	return 0, false, false
+}
+
+func toInt(v interface{}) (int, bool) {
+	num, isInt, ok := toNumber(v)
+	if !ok || !isInt {
+		return 0, false
+	}
+	return int(num), true
+}
+
+func toString(v interface{}) string {
+	switch s := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return s
+	case float64:
+		return strconv.FormatFloat(s, 'f', -1, 64)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case int, int32, int64, float32, float64:
+		return "number"
+	case map[string]interface{}, map[string]string, map[string]float64:
+		return "map"
+	case []interface{}, []string:
+		return "list"
+	}
+	return fmt.Sprintf("%T", v)
+}
@@ -0,0 +1,201 @@
+package expr
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"Go-Mini-Spark/pkg/types"
+)
+
+// csvRow is a row as ReadCSV builds it: every field is a string.
+var csvRow = types.Row{
+	Key: "1",
+	Value: map[string]interface{}{
+		"name":       "Apple",
+		"price":      "3",
+		"qty":        "4",
+		"unit price": "2.5",
+		"tags":       "red;fruit",
+	},
+}
+
+func TestEval(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		row  types.Row
+		want interface{}
+	}{
+		// precedence and associativity
+		{"mul before add", "1 + 2 * 3", types.Row{}, 7},
+		{"parentheses", "(1 + 2) * 3", types.Row{}, 9},
+		{"left associative", "10 - 4 - 3", types.Row{}, 3},
+		{"division is float", "7 / 2", types.Row{}, 3.5},
+		{"modulo", "7 % 3", types.Row{}, 1},
+		{"unary minus", "-2 * 3", types.Row{}, -6},
+		{"comparison after arithmetic", "1 + 1 == 2", types.Row{}, true},
+		{"and before or", "true || false && false", types.Row{}, true},
+		{"not binds tighter than and", "!false && false", types.Row{}, false},
+		{"keywords", "not (1 > 2) and 2 > 1 or false", types.Row{}, true},
+
+		// rows
+		{"key", "key", types.Row{Key: "a", Value: 1}, "a"},
+		{"value", "value * 2", types.Row{Key: "a", Value: 21}, 42},
+		{"csv fields are numbers in arithmetic", "value.price * value.qty", csvRow, 12},
+		{"quoted field", `value["unit price"] * 2`, csvRow, 5.0},
+		{"csv comparison", "value.price * value.qty > 10", csvRow, true},
+		{"string concatenation", `value.name + "!"`, csvRow, "Apple!"},
+		{"object", "{name: lower(value.name), total: value.price * value.qty}", csvRow,
+			map[string]interface{}{"name": "apple", "total": 12}},
+
+		// null
+		{"missing field is null", "value.missing", csvRow, nil},
+		{"arithmetic on null", "value.missing + 1", csvRow, nil},
+		{"comparison with null", "value.missing > 1", csvRow, false},
+		{"null equals null", "value.missing == null", csvRow, true},
+		{"field of null", "value.a.b", types.Row{Value: map[string]interface{}{}}, nil},
+
+		// builtins
+		{"upper", "upper(value.name)", csvRow, "APPLE"},
+		{"lower", `lower("ABC")`, types.Row{}, "abc"},
+		{"trim", `trim("  x ")`, types.Row{}, "x"},
+		{"contains", `contains(value.tags, "fruit")`, csvRow, true},
+		{"startsWith", `startsWith(value.name, "Ap")`, csvRow, true},
+		{"endsWith", `endsWith(value.name, "x")`, csvRow, false},
+		{"split and index", `split(value.tags, ";")[1]`, csvRow, "fruit"},
+		{"negative index", `split(value.tags, ";")[-1]`, csvRow, "fruit"},
+		{"index out of range", `split(value.tags, ";")[5]`, csvRow, nil},
+		{"replace", `replace(value.tags, ";", ",")`, csvRow, "red,fruit"},
+		{"substr", `substr(value.name, 1, 3)`, csvRow, "ppl"},
+		{"substr clamps", `substr(value.name, 3, 100)`, csvRow, "le"},
+		{"concat", `concat(key, "-", value.name)`, csvRow, "1-Apple"},
+		{"matches", `matches(value.name, "^A.*e$")`, csvRow, true},
+		{"len", "len(value.name)", csvRow, 5},
+		{"abs", "abs(-2)", types.Row{}, 2.0},
+		{"floor", "floor(2.7)", types.Row{}, 2.0},
+		{"ceil", "ceil(2.1)", types.Row{}, 3.0},
+		{"sqrt", "sqrt(16)", types.Row{}, 4.0},
+		{"round", "round(2.345, 2)", types.Row{}, 2.35},
+		{"min", "min(3, null, 1, 2)", types.Row{}, 1},
+		{"max", "max(value.price, value.qty)", csvRow, "4"},
+		{"int", `int("42")`, types.Row{}, 42},
+		{"float", `float("2.5")`, types.Row{}, 2.5},
+		{"str", "str(1.5)", types.Row{}, "1.5"},
+		{"isNull", "isNull(value.missing)", csvRow, true},
+		{"if", "if(value.qty > 3, \"many\", \"few\")", csvRow, "many"},
+		{"if is lazy", "if(value.qty == 0, 0, value.price / value.qty)", types.Row{Value: map[string]interface{}{"qty": 0}}, 0},
+		{"coalesce", `coalesce(value.missing, value.name)`, csvRow, "Apple"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := Parse(tt.src)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.src, err)
+			}
+			got, err := e.Eval(tt.row)
+			if err != nil {
+				t.Fatalf("Eval(%q): %v", tt.src, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Eval(%q) = %#v, want %#v", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		row  types.Row
+		want string
+	}{
+		{"string times number", "value.name * 2", csvRow, "cannot apply '*' to string and number"},
+		{"negate string", "-value.name", csvRow, "cannot negate string"},
+		{"compare string and number", "value.name > 1", csvRow, "cannot compare string and number"},
+		{"field of string", "value.name.first", csvRow, "cannot read field 'first' of string"},
+		{"non-boolean and", "value.name && true", csvRow, "expected a boolean, got string"},
+		{"division by zero", "value.price / 0", csvRow, "division by zero"},
+		{"modulo by zero", "5 % 0", types.Row{}, "modulo by zero"},
+		{"list index", `split(value.tags, ";")["a"]`, csvRow, "list index must be an integer"},
+		{"invalid regexp", `matches(value.name, "(")`, csvRow, "matches: error parsing regexp"},
+		{"int of text", "int(value.name)", csvRow, `int: cannot convert "Apple" to an integer`},
+		{"len of number", "len(1)", types.Row{}, "len: cannot take the length of number"},
+		{"if condition", `if(value.name, 1, 2)`, csvRow, "if: expected a boolean"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := Parse(tt.src)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.src, err)
+			}
+			_, err = e.Eval(tt.row)
+			if err == nil {
+				t.Fatalf("Eval(%q) succeeded, want error containing %q", tt.src, tt.want)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Eval(%q) error = %q, want it to contain %q", tt.src, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvalBool(t *testing.T) {
+	tests := []struct {
+		src     string
+		want    bool
+		wantErr string
+	}{
+		{src: "value.qty > 3", want: true},
+		{src: "value.missing", want: false},
+		{src: "value.price", wantErr: "expected a boolean, got string"},
+	}
+
+	for _, tt := range tests {
+		e, err := Parse(tt.src)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.src, err)
+		}
+		got, err := e.EvalBool(csvRow)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("EvalBool(%q) error = %v, want %q", tt.src, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("EvalBool(%q) = %v, %v, want %v", tt.src, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"", "empty expression"},
+		{"1 +", "unexpected end of expression"},
+		{"(1 + 2", "unexpected end of expression"},
+		{"1 < 2 < 3", "unexpected '<'"},
+		{"price * 2", "unknown identifier 'price'"},
+		{"nope(1)", "unknown function 'nope'"},
+		{"upper(1, 2)", "takes 1 argument, got 2"},
+		{`"abc`, "unterminated string"},
+		{"value # 1", "unexpected character '#'"},
+	}
+
+	for _, tt := range tests {
+		_, err := Parse(tt.src)
+		if err == nil {
+			t.Errorf("Parse(%q) succeeded, want error containing %q", tt.src, tt.want)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Parse(%q) error = %q, want it to contain %q", tt.src, err, tt.want)
+		}
+	}
+}
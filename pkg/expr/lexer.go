@@ -0,0 +1,110 @@
+package expr
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokString
+	tokIdent
+	tokOp // operators and punctuation
+)
+
+type token struct {
+	kind tokenKind
+	text string // for tokString, the unquoted contents
+	pos  int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of expression"
+	case tokString:
+		return fmt.Sprintf("%q", t.text)
+	}
+	return fmt.Sprintf("'%s'", t.text)
+}
+
+// operators are tried in order, so two-character operators come first.
+var operators = []string{
+	"==", "!=", "<=", ">=", "&&", "||",
+	"+", "-", "*", "/", "%", "<", ">", "!",
+	"(", ")", "[", "]", "{", "}", ",", ".", ":",
+}
+
+// tokenize splits src into tokens.
+func tokenize(src string) ([]token, error) {
+	var tokens []token
+	runes := []rune(src)
+
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+
+		case unicode.IsDigit(c):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: string(runes[start:i]), pos: start})
+
+		case unicode.IsLetter(c) || c == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: string(runes[start:i]), pos: start})
+
+		case c == '"' || c == '\'':
+			start := i
+			var sb strings.Builder
+			i++
+			for i < len(runes) && runes[i] != c {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+					switch runes[i] {
+					case 'n':
+						sb.WriteRune('\n')
+					case 't':
+						sb.WriteRune('\t')
+					default:
+						sb.WriteRune(runes[i])
+					}
+				} else {
+					sb.WriteRune(runes[i])
+				}
+				i++
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated string starting at position %d", start)
+			}
+			i++
+			tokens = append(tokens, token{kind: tokString, text: sb.String(), pos: start})
+
+		default:
+			matched := false
+			for _, op := range operators {
+				if strings.HasPrefix(string(runes[i:]), op) {
+					tokens = append(tokens, token{kind: tokOp, text: op, pos: i})
+					i += len([]rune(op))
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected character '%c' at position %d", c, i)
+			}
+		}
+	}
+
+	return append(tokens, token{kind: tokEOF, pos: len(runes)}), nil
+}
@@ -0,0 +1,356 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// node is an element of the parsed expression tree.
+type node interface {
+	eval(row env) (interface{}, error)
+}
+
+type literal struct {
+	value interface{}
+}
+
+// variable is one of the row identifiers, key or value.
+type variable struct {
+	name string
+}
+
+// field reads a named field of a map, e.g. value.price.
+type field struct {
+	target node
+	name   string
+}
+
+// index reads a map entry or list element, e.g. value["unit price"].
+type index struct {
+	target node
+	index  node
+}
+
+type unary struct {
+	op      string
+	operand node
+}
+
+type binary struct {
+	op          string
+	left, right node
+}
+
+type call struct {
+	fn   *builtin
+	name string
+	args []node
+}
+
+// object builds a map, e.g. {name: value.name, total: value.price * value.qty}.
+type object struct {
+	keys   []string
+	values []node
+}
+
+// variables are the identifiers an expression may reference.
+var variables = map[string]bool{"key": true, "value": true}
+
+var keywords = map[string]interface{}{"true": true, "false": false, "null": nil}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// accept consumes the next token if it is one of ops (or one of the word
+// operators such as "and").
+func (p *parser) accept(ops ...string) (string, bool) {
+	t := p.peek()
+	if t.kind != tokOp && t.kind != tokIdent {
+		return "", false
+	}
+	for _, op := range ops {
+		if t.text == op {
+			p.next()
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *parser) expect(op string) error {
+	if _, ok := p.accept(op); !ok {
+		return p.unexpected()
+	}
+	return nil
+}
+
+func (p *parser) unexpected() error {
+	t := p.peek()
+	return fmt.Errorf("unexpected %s at position %d", t, t.pos)
+}
+
+// Operator precedence, lowest first:
+//
+//	|| or
+//	&& and
+//	== != < <= > >=
+//	+ -
+//	* / %
+//	unary - ! not
+//	.field [index] call()
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("||", "or"); !ok {
+			return left, nil
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &binary{op: "||", left: left, right: right}
+	}
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseComparison()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("&&", "and"); !ok {
+			return left, nil
+		}
+		right, err := p.parseComparison()
+		if err != nil {
+			return nil, err
+		}
+		left = &binary{op: "&&", left: left, right: right}
+	}
+}
+
+// Comparisons do not chain: a < b < c is a syntax error.
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	op, ok := p.accept("==", "!=", "<", "<=", ">", ">=")
+	if !ok {
+		return left, nil
+	}
+	right, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	return &binary{op: op, left: left, right: right}, nil
+}
+
+func (p *parser) parseAdditive() (node, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept("+", "-")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		left = &binary{op: op, left: left, right: right}
+	}
+}
+
+func (p *parser) parseMultiplicative() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept("*", "/", "%")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &binary{op: op, left: left, right: right}
+	}
+}
+
+func (p *parser) parseUnary() (node, error) {
+	op, ok := p.accept("-", "!", "not")
+	if !ok {
+		return p.parsePostfix()
+	}
+	operand, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	if op == "not" {
+		op = "!"
+	}
+	return &unary{op: op, operand: operand}, nil
+}
+
+func (p *parser) parsePostfix() (node, error) {
+	n, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch {
+		case p.peek().kind == tokOp && p.peek().text == ".":
+			p.next()
+			t := p.next()
+			if t.kind != tokIdent {
+				return nil, fmt.Errorf("expected field name after '.' at position %d", t.pos)
+			}
+			n = &field{target: n, name: t.text}
+
+		case p.peek().kind == tokOp && p.peek().text == "[":
+			p.next()
+			idx, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			n = &index{target: n, index: idx}
+
+		default:
+			return n, nil
+		}
+	}
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		if i, err := strconv.Atoi(t.text); err == nil {
+			return &literal{value: i}, nil
+		}
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number '%s' at position %d", t.text, t.pos)
+		}
+		return &literal{value: f}, nil
+
+	case tokString:
+		return &literal{value: t.text}, nil
+
+	case tokIdent:
+		if v, ok := keywords[t.text]; ok {
+			return &literal{value: v}, nil
+		}
+		if _, ok := p.accept("("); ok {
+			return p.parseCall(t)
+		}
+		if !variables[t.text] {
+			return nil, fmt.Errorf("unknown identifier '%s' at position %d (use key, value or value.<field>)", t.text, t.pos)
+		}
+		return &variable{name: t.text}, nil
+
+	case tokOp:
+		switch t.text {
+		case "(":
+			n, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return n, nil
+		case "{":
+			return p.parseObject()
+		}
+	}
+	return nil, fmt.Errorf("unexpected %s at position %d", t, t.pos)
+}
+
+// parseCall parses the arguments of a call to the builtin name, whose opening
+// parenthesis was already consumed.
+func (p *parser) parseCall(name token) (node, error) {
+	fn, exists := builtins[name.text]
+	if !exists {
+		return nil, fmt.Errorf("unknown function '%s' at position %d", name.text, name.pos)
+	}
+
+	var args []node
+	if _, ok := p.accept(")"); !ok {
+		for {
+			arg, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if _, ok := p.accept(","); !ok {
+				break
+			}
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(args) < fn.minArgs || (fn.maxArgs >= 0 && len(args) > fn.maxArgs) {
+		return nil, fmt.Errorf("function '%s' at position %d takes %s, got %d", name.text, name.pos, fn.arity(), len(args))
+	}
+	return &call{fn: fn, name: name.text, args: args}, nil
+}
+
+// parseObject parses the fields of an object literal, whose opening brace was
+// already consumed. Keys are identifiers or strings.
+func (p *parser) parseObject() (node, error) {
+	obj := &object{}
+	if _, ok := p.accept("}"); ok {
+		return obj, nil
+	}
+	for {
+		t := p.next()
+		if t.kind != tokIdent && t.kind != tokString {
+			return nil, fmt.Errorf("expected field name at position %d, got %s", t.pos, t)
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		value, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		obj.keys = append(obj.keys, t.text)
+		obj.values = append(obj.values, value)
+
+		if _, ok := p.accept(","); !ok {
+			break
+		}
+	}
+	if err := p.expect("}"); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
@@ -1,6 +1,9 @@
 package types
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 type Job struct {
     ID     int
@@ -24,36 +27,188 @@ const (
 	ReduceByKeyOp
 	ShuffleOp
 	JoinOp
+	GroupByKeyOp
+	SortOp
+	CountOp
+	TakeOp
+	TopOp
+	MapPartitionsOp          // func([]Row) []Row sobre la partición completa
+	MapPartitionsWithIndexOp // func(partitionIndex int, rows []Row) []Row
+	DistinctOp
+	SampleOp
+	FoldOp
+	CombineByKeyOp
+	StatsOp
+	HistogramOp
+	ApproxDistinctOp
+	QuantileSketchOp
+	MapValuesOp     // func(Row) Row, conserva Row.Key
+	FlatMapValuesOp // func(Row) []Row, conserva Row.Key
+	KeysOp
+	ValuesOp
+	KeyByOp         // func(Row) interface{} calcula la nueva Row.Key
+	BroadcastJoinOp // join de la partición contra filas difundidas por el driver
+	PipeOp          // filas como líneas JSON a un ejecutable del worker, ver PipeArgs
+)
+
+// FuncKind es la clase de una función de utils.FuncRegistry según su firma
+type FuncKind int
+
+const (
+	MapperKind                 FuncKind = iota // func(Row) Row
+	PredicateKind                              // func(Row) bool
+	FlatMapperKind                             // func(Row) []Row
+	ReducerKind                                // func(Row, Row) Row
+	KeyExtractorKind                           // func(Row) interface{}
+	ComparatorKind                             // func(Row, Row) int
+	PartitionMapperKind                        // func([]Row) []Row
+	IndexedPartitionMapperKind                 // func(int, []Row) []Row
 )
 
+var funcKindNames = []string{
+	"mapper", "predicate", "flat-mapper", "reducer", "key extractor",
+	"comparator", "partition mapper", "indexed partition mapper",
+}
+
+func (k FuncKind) String() string {
+	if k < 0 || int(k) >= len(funcKindNames) {
+		return fmt.Sprintf("FuncKind(%d)", int(k))
+	}
+	return funcKindNames[k]
+}
+
+// FuncInfo describe una función registrada, es la respuesta de Driver.ListFunctions
+type FuncInfo struct {
+	Name          string
+	Kind          FuncKind
+	Parameterized bool // recibe los argumentos de la solicitud (ver MapArgs.Args)
+	Accumulators  bool // actualiza acumuladores
+}
+
 type ReadCSVArg struct {
 	FilePath      string
 	KeyColumn     string
+	NumPartitions int // 0 usa la cantidad por defecto del driver
+}
+
+type ReadTextArg struct {
+	FilePath      string
+	NumPartitions int // 0 usa la cantidad por defecto del driver
+}
+
+// RepartitionArgs es la solicitud RPC de Driver.Repartition y Driver.Coalesce
+type RepartitionArgs struct {
+	RDDID         int
+	NumPartitions int
+}
+
+// PartitionByArgs es la solicitud RPC de Driver.PartitionBy
+type PartitionByArgs struct {
+	RDDID         int
+	NumPartitions int    // 0 conserva la cantidad de particiones del RDD
+	Partitioner   string // "hash" (por defecto), "range" o un nombre de utils.PartitionerRegistry
+}
+
+// MapArgs es la solicitud RPC de Driver.Map
+type MapArgs struct {
+	RDDID    int
+	FuncName string        // nombre de la función en utils.FuncRegistry
+	Args     []interface{} // opcional, argumentos de una función parametrizada, se serializan en Transformation.Args
+}
+
+// Filter, FlatMap y Reduce reciben la misma solicitud que Map
+type FilterArgs = MapArgs
+type FlatMapArgs = MapArgs
+type ReduceArgs = MapArgs
+type ReduceByKeyArgs = MapArgs
+type MapPartitionsArgs = MapArgs
+
+// PipeArgs es la solicitud RPC de Driver.Pipe. Command es el nombre de un
+// ejecutable en el directorio -pipe-dir del worker; recibe cada fila como una
+// línea JSON {"key": ..., "value": ...} en stdin y escribe las filas
+// resultantes con el mismo formato en stdout.
+type PipeArgs struct {
+	RDDID   int
+	Command string
+	Args    []string // argumentos del ejecutable
+	Timeout int      // segundos por partición, 0 usa el valor por defecto del driver
+}
+
+// ExprArgs es la solicitud RPC de Driver.MapExpr, FilterExpr y KeyByExpr
+type ExprArgs struct {
+	RDDID int
+	Expr  string // ej. "value.price * value.qty > 100", ver pkg/expr
 }
 
 type Transformation struct {
 	Type     TransformationType
 	FuncName string // nombre de la función
 	Args     []byte // opcional si la función recibe parámetros
+	Expr     string // expresión de pkg/expr, se usa en lugar de FuncName si no es vacía
+	Options  map[string]interface{} // parámetros propios de la operación (ej. orden de SortOp)
+	RDDID    int                    // RDD que aplica la transformación
 }
 
 type Task struct {
 	ID              int
 	PartitionID     int
+	PartitionIndex  int // posición de la partición dentro del RDD
     Data            []Row 
 	Transformations []Transformation
+	Inputs          []Task // si no está vacío, sus filas concatenadas reemplazan a Data (ver Driver.Coalesce)
 }
 
 type TaskJoin struct {
 	ID          int
     LeftRows    []Row
     RightRows   []Row
+	JoinType    JoinType
+	Collision   CollisionPolicy
+}
+
+// BroadcastVar es un valor de solo lectura que el driver difunde a los workers
+// bajo ID, es la solicitud de Driver.Broadcast y la respuesta de Driver.GetBroadcast
+type BroadcastVar struct {
+	ID    string
+	Value interface{}
 }
 
 type TaskReply struct {
-	ID     string
-	status int
-	Data   []Row
+	ID           string
+	status       int
+	Data         []Row
+	Accumulators map[int]AccumulatorUpdates // cambios a los acumuladores por RDD de la transformación que los hizo
+}
+
+type AccumulatorKind int
+
+const (
+	SumAccumulator  AccumulatorKind = iota // suma valores numéricos
+	ListAccumulator                        // junta todos los valores agregados
+	SetAccumulator                         // junta los valores distintos
+)
+
+// AccumulatorUpdates son los cambios a los acumuladores hechos por una task,
+// por nombre de acumulador
+type AccumulatorUpdates struct {
+	Sums  map[string]float64
+	Lists map[string][]interface{}
+	Sets  map[string][]interface{}
+}
+
+// AccumulatorArgs es la solicitud RPC de Driver.CreateAccumulator
+type AccumulatorArgs struct {
+	Name string
+	Kind AccumulatorKind
+}
+
+// AccumulatorValue es la respuesta de Driver.GetAccumulator, Sum se usa en los
+// acumuladores SumAccumulator y Values en los demás
+type AccumulatorValue struct {
+	Name   string
+	Kind   AccumulatorKind
+	Sum    float64
+	Values []interface{}
 }
 
 type WorkerInfo struct {
@@ -61,6 +216,14 @@ type WorkerInfo struct {
 	Endpoint string
 	Status   int
 	LastSeen time.Time
+	Plugins  []PluginInfo // plugins cargados por el worker, ver utils.LoadPluginDir
+}
+
+// PluginInfo describe un plugin de funciones cargado por un worker
+type PluginInfo struct {
+	Name      string     // namespace de sus funciones, registradas como "<Name>.<función>"
+	Path      string
+	Functions []FuncInfo
 }
 
 // WorkerHeartbeatInfo es serializable para RPC
@@ -70,6 +233,7 @@ type Heartbeat struct {
 	ActiveTasks   int
 	Endpoint      string
 	LastHeartbeat time.Time
+	Plugins       []PluginInfo
 }
 
 type Row struct {
@@ -77,7 +241,166 @@ type Row struct {
     Value interface{}
 }
 
+// JoinType indica qué filas sin coincidencia conserva un join
+type JoinType int
+
+const (
+	InnerJoin JoinType = iota
+	LeftOuterJoin
+	RightOuterJoin
+	FullOuterJoin
+	LeftSemiJoin // filas de RddID1 con al menos una coincidencia
+	LeftAntiJoin // filas de RddID1 sin coincidencias
+)
+
+// CollisionPolicy indica qué hacer cuando ambos lados tienen una columna con el mismo nombre
+type CollisionPolicy int
+
+const (
+	PrefixColumns   CollisionPolicy = iota // renombra a left_<col> y right_<col>
+	FailOnCollision                        // el join falla con un error
+)
+
 type JoinRequest struct {
+	RddID1    int
+	RddID2    int
+	JoinType  JoinType
+	Collision CollisionPolicy
+}
+
+// SortArgs es la solicitud RPC de Driver.SortBy y Driver.SortByKey. FuncName
+// es la función que extrae la clave de orden, SortByKey la ignora.
+type SortArgs struct {
+	RDDID     int
+	FuncName  string
+	Ascending bool
+}
+
+// TakeArgs es la solicitud RPC de Driver.Take
+type TakeArgs struct {
+	RDDID int
+	N     int
+}
+
+// TopArgs es la solicitud RPC de Driver.Top, FuncName es un comparador
+// func(a, b types.Row) int y puede quedar vacío para comparar por Value
+type TopArgs struct {
+	RDDID    int
+	N        int
+	FuncName string
+}
+
+// SampleArgs es la solicitud RPC de Driver.Sample
+type SampleArgs struct {
+	RDDID           int
+	WithReplacement bool
+	Fraction        float64 // sin reemplazo es la probabilidad de cada fila, con reemplazo la cantidad esperada de copias
+	Seed            int64
+}
+
+// RandomSplitArgs es la solicitud RPC de Driver.RandomSplit, los pesos se normalizan
+type RandomSplitArgs struct {
+	RDDID   int
+	Weights []float64
+	Seed    int64
+}
+
+// SampleByKeyArgs es la solicitud RPC de Driver.SampleByKey, Fractions se indexa
+// con la representación fmt de cada key
+type SampleByKeyArgs struct {
+	RDDID           int
+	WithReplacement bool
+	Fractions       map[string]float64
+	Seed            int64
+}
+
+// FoldArgs es la solicitud RPC de Driver.Fold
+type FoldArgs struct {
+	RDDID    int
+	Zero     Row
+	FuncName string
+}
+
+// AggregateArgs es la solicitud RPC de Driver.Aggregate. SeqOp agrega las filas
+// de cada partición al acumulador y CombOp combina los acumuladores parciales.
+type AggregateArgs struct {
+	RDDID  int
+	Zero   Row
+	SeqOp  string
+	CombOp string
+}
+
+// CombineByKeyArgs es la solicitud RPC de Driver.CombineByKey
+type CombineByKeyArgs struct {
+	RDDID          int
+	CreateCombiner string // func(Row) Row, crea el acumulador con la primera fila de cada key
+	MergeValue     string // func(acc, Row) Row, agrega una fila al acumulador
+	MergeCombiners string // func(acc, acc) Row, combina acumuladores de distintas particiones
+}
+
+// StatsArgs es la solicitud RPC de Driver.Stats. Field es la columna numérica
+// de las filas de ReadCSV, vacío usa el Value completo.
+type StatsArgs struct {
+	RDDID int
+	Field string
+}
+
+type StatsReply struct {
+	Count    int64
+	Sum      float64
+	Mean     float64
+	Variance float64
+	Stdev    float64
+	Min      float64
+	Max      float64
+	Skipped  int64 // filas sin un valor numérico
+}
+
+// HistogramArgs es la solicitud RPC de Driver.Histogram. Si Edges está vacío se
+// usan Buckets intervalos iguales entre el mínimo y el máximo.
+type HistogramArgs struct {
+	RDDID   int
+	Field   string
+	Buckets int
+	Edges   []float64
+}
+
+type HistogramReply struct {
+	Edges  []float64
+	Counts []int
+}
+
+// ApproxDistinctArgs es la solicitud RPC de Driver.CountApproxDistinct. Field
+// vacío cuenta filas completas distintas. RelativeSD es el error relativo
+// deseado, 0 usa el valor por defecto.
+type ApproxDistinctArgs struct {
+	RDDID      int
+	Field      string
+	RelativeSD float64
+}
+
+// ApproxQuantileArgs es la solicitud RPC de Driver.ApproxQuantile. K controla
+// el tamaño y la precisión del sketch, 0 usa el valor por defecto.
+type ApproxQuantileArgs struct {
+	RDDID         int
+	Field         string
+	Probabilities []float64
+	K             int
+}
+
+// SaveArgs es la solicitud RPC de Driver.SaveCSV
+type SaveArgs struct {
+	RDDID    int
+	FilePath string
+}
+
+type CoGroupRequest struct {
+	RddID1 int
+	RddID2 int
+}
+
+// SetOpRequest es la solicitud RPC de Union, Intersection y Subtract
+type SetOpRequest struct {
 	RddID1 int
 	RddID2 int
 }
@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"sync"
+
+	"Go-Mini-Spark/pkg/types"
+)
+
+// Accumulators collects the accumulator updates made while one task runs. The
+// worker returns them in types.TaskReply and the driver merges them.
+type Accumulators struct {
+	mu      sync.Mutex
+	updates types.AccumulatorUpdates
+}
+
+// AccumulatorFunc is a registry entry that updates accumulators. The worker
+// calls it once per task with the task's Accumulators and runs the function it
+// returns, which must have one of the usual registry signatures.
+type AccumulatorFunc func(acc *Accumulators) interface{}
+
+func NewAccumulators() *Accumulators {
+	return &Accumulators{updates: types.AccumulatorUpdates{
+		Sums:  make(map[string]float64),
+		Lists: make(map[string][]interface{}),
+		Sets:  make(map[string][]interface{}),
+	}}
+}
+
+// Add increments the sum accumulator name by n.
+func (a *Accumulators) Add(name string, n float64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.updates.Sums[name] += n
+}
+
+// Append adds value to the list accumulator name.
+func (a *Accumulators) Append(name string, value interface{}) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.updates.Lists[name] = append(a.updates.Lists[name], value)
+}
+
+// AddToSet adds value to the set accumulator name, duplicates are dropped
+// when the driver merges the update.
+func (a *Accumulators) AddToSet(name string, value interface{}) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.updates.Sets[name] = append(a.updates.Sets[name], value)
+}
+
+// Updates returns the updates made so far.
+func (a *Accumulators) Updates() types.AccumulatorUpdates {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.updates
+}
@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"regexp"
+	"sync"
+
+	"Go-Mini-Spark/pkg/types"
+)
+
+// EncodeArgs serializes the arguments of a parameterized function so they can
+// travel in Transformation.Args.
+func EncodeArgs(args []interface{}) ([]byte, error) {
+	if len(args) == 0 {
+		return nil, nil
+	}
+	var buffer bytes.Buffer
+	if err := gob.NewEncoder(&buffer).Encode(args); err != nil {
+		return nil, fmt.Errorf("cannot encode function args: %w", err)
+	}
+	return buffer.Bytes(), nil
+}
+
+// DecodeArgs is the inverse of EncodeArgs, empty data decodes to no args.
+func DecodeArgs(data []byte) ([]interface{}, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	var args []interface{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&args); err != nil {
+		return nil, fmt.Errorf("cannot decode function args: %w", err)
+	}
+	return args, nil
+}
+
+// bindArgs turns a parameterized registry function, one that receives the
+// decoded args after the row, into the plain function of the same kind.
+// Functions without parameters are returned as they are.
+func bindArgs(fn interface{}, args []interface{}) interface{} {
+	switch f := fn.(type) {
+	case func(types.Row, []interface{}) types.Row:
+		return func(r types.Row) types.Row { return f(r, args) }
+	case func(types.Row, []interface{}) bool:
+		return func(r types.Row) bool { return f(r, args) }
+	case func(types.Row, []interface{}) []types.Row:
+		return func(r types.Row) []types.Row { return f(r, args) }
+	case func(types.Row, []interface{}) interface{}:
+		return func(r types.Row) interface{} { return f(r, args) }
+	case func(types.Row, types.Row, []interface{}) types.Row:
+		return func(a, b types.Row) types.Row { return f(a, b, args) }
+	}
+	return fn
+}
+
+// stringArg returns args[i] as a string.
+func stringArg(args []interface{}, i int) (string, bool) {
+	if i >= len(args) {
+		return "", false
+	}
+	str, ok := args[i].(string)
+	return str, ok
+}
+
+// compiledRegexps caches the patterns used by MatchesRegex, so each one is
+// compiled once per worker instead of once per row.
+var compiledRegexps sync.Map
+
+func compileRegexp(pattern string) (*regexp.Regexp, error) {
+	if re, ok := compiledRegexps.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	compiledRegexps.Store(pattern, re)
+	return re, nil
+}
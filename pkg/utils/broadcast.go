@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"sync"
+)
+
+// broadcasts caches the broadcast values a worker has already fetched. Values
+// are read-only, so a cached copy never goes stale; the driver removes it with
+// DropBroadcast when the value is released.
+var broadcasts = struct {
+	sync.Mutex
+	values map[string]interface{}
+	fetch  func(id string) (interface{}, error)
+}{values: make(map[string]interface{})}
+
+// SetBroadcastFetcher sets how missing broadcast values are obtained, the
+// worker installs a function that asks the driver for them.
+func SetBroadcastFetcher(fetch func(id string) (interface{}, error)) {
+	broadcasts.Lock()
+	defer broadcasts.Unlock()
+	broadcasts.fetch = fetch
+}
+
+// GetBroadcast returns the broadcast value published under id, fetching it only
+// the first time. Concurrent callers wait for that single fetch.
+func GetBroadcast(id string) (interface{}, error) {
+	broadcasts.Lock()
+	defer broadcasts.Unlock()
+
+	if value, exists := broadcasts.values[id]; exists {
+		return value, nil
+	}
+	if broadcasts.fetch == nil {
+		return nil, fmt.Errorf("broadcast '%s' not available: no fetcher configured", id)
+	}
+
+	value, err := broadcasts.fetch(id)
+	if err != nil {
+		return nil, err
+	}
+	broadcasts.values[id] = value
+	return value, nil
+}
+
+// DropBroadcast removes the cached value of id, so the next GetBroadcast
+// fetches it again. The driver calls it when a broadcast is released.
+func DropBroadcast(id string) {
+	broadcasts.Lock()
+	defer broadcasts.Unlock()
+	delete(broadcasts.values, id)
+}
+
+// BroadcastValue is the accessor for registry functions. The second result is
+// false, and the error is logged, when the value cannot be obtained.
+func BroadcastValue(id string) (interface{}, bool) {
+	value, err := GetBroadcast(id)
+	if err != nil {
+		log.Printf("BroadcastValue: %v\n", err)
+		return nil, false
+	}
+	return value, true
+}
@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+
+	"Go-Mini-Spark/pkg/types"
+)
+
+func TestJoin(t *testing.T) {
+	left := []types.Row{
+		{Key: 1, Value: "a"},
+		{Key: "1", Value: "b"},
+		{Key: 2, Value: "c"},
+	}
+	right := []types.Row{
+		{Key: 1, Value: "x"},
+		{Key: 1.0, Value: "y"},
+		{Key: "2", Value: "z"},
+	}
+
+	tests := []struct {
+		name     string
+		joinType types.JoinType
+		want     []types.Row
+	}{
+		{"inner compares key types", types.InnerJoin, []types.Row{
+			{Key: 1, Value: map[string]interface{}{"left_value": "a", "right_value": "x"}},
+		}},
+		{"left semi", types.LeftSemiJoin, []types.Row{
+			{Key: 1, Value: "a"},
+		}},
+		{"left anti", types.LeftAntiJoin, []types.Row{
+			{Key: "1", Value: "b"},
+			{Key: 2, Value: "c"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Join(left, right, tt.joinType, types.PrefixColumns)
+			if err != nil {
+				t.Fatalf("Join: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Join = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJoinNilKeys(t *testing.T) {
+	left := []types.Row{
+		{Key: nil, Value: "a"},
+		{Key: 1, Value: "b"},
+	}
+	right := []types.Row{
+		{Key: nil, Value: "x"},
+		{Key: 1, Value: "y"},
+	}
+	matched := types.Row{Key: 1, Value: map[string]interface{}{"left_value": "b", "right_value": "y"}}
+	leftOnly := types.Row{Key: nil, Value: map[string]interface{}{"value": "a"}}
+	rightOnly := types.Row{Key: nil, Value: map[string]interface{}{"value": "x"}}
+
+	tests := []struct {
+		name     string
+		joinType types.JoinType
+		want     []types.Row
+	}{
+		{"inner", types.InnerJoin, []types.Row{matched}},
+		{"left outer", types.LeftOuterJoin, []types.Row{leftOnly, matched}},
+		{"right outer", types.RightOuterJoin, []types.Row{matched, rightOnly}},
+		{"full outer", types.FullOuterJoin, []types.Row{leftOnly, matched, rightOnly}},
+		{"left semi", types.LeftSemiJoin, []types.Row{{Key: 1, Value: "b"}}},
+		{"left anti", types.LeftAntiJoin, []types.Row{{Key: nil, Value: "a"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Join(left, right, tt.joinType, types.PrefixColumns)
+			if err != nil {
+				t.Fatalf("Join: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Join = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJoinOnlyNilKeys(t *testing.T) {
+	// rows read with ReadRDDTextFile have no key, joining them matches nothing
+	rows := []types.Row{{Value: "a"}, {Value: "b"}, {Value: "c"}}
+	got, err := Join(rows, rows, types.InnerJoin, types.PrefixColumns)
+	if err != nil {
+		t.Fatalf("Join: %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("Join of nil keys = %v, want no rows", got)
+	}
+}
@@ -0,0 +1,129 @@
+package utils
+
+import (
+	"fmt"
+
+	"Go-Mini-Spark/pkg/types"
+)
+
+// Partitioner decides which partition each key belongs to. Two RDDs whose
+// partitioners are compatible keep every key in the same partition index, so
+// they can be joined partition by partition without a shuffle.
+type Partitioner interface {
+	NumPartitions() int
+	Partition(key interface{}) int
+	Compatible(other Partitioner) bool
+}
+
+// PartitionerRegistry holds the custom partitioning functions available to
+// PartitionBy. Each function receives the key and the number of partitions and
+// returns the partition index.
+var PartitionerRegistry = map[string]func(key interface{}, numPartitions int) int{
+	// keys with the same first character end up together
+	"FirstLetter": func(key interface{}, numPartitions int) int {
+		str := fmt.Sprintf("%v", key)
+		if str == "" {
+			return 0
+		}
+		return HashPartition(str[:1], numPartitions)
+	},
+}
+
+// HashPartitioner places keys by the hash of their fmt representation, the
+// same placement Shuffle uses.
+type HashPartitioner struct {
+	N int
+}
+
+func (p HashPartitioner) NumPartitions() int {
+	return p.N
+}
+
+func (p HashPartitioner) Partition(key interface{}) int {
+	return HashPartition(fmt.Sprintf("%v", key), p.N)
+}
+
+func (p HashPartitioner) Compatible(other Partitioner) bool {
+	o, ok := other.(HashPartitioner)
+	return ok && o.N == p.N
+}
+
+// RangePartitioner places keys in contiguous ranges delimited by Bounds (see
+// RangeBounds). When Ascending is false the ranges are assigned in reverse
+// order, so collecting the partitions in order yields descending keys.
+type RangePartitioner struct {
+	N         int
+	Bounds    []interface{}
+	Ascending bool
+}
+
+func (p RangePartitioner) NumPartitions() int {
+	return p.N
+}
+
+func (p RangePartitioner) Partition(key interface{}) int {
+	partition := RangePartition(key, p.Bounds)
+	if !p.Ascending {
+		partition = p.N - 1 - partition
+	}
+	return partition
+}
+
+func (p RangePartitioner) Compatible(other Partitioner) bool {
+	o, ok := other.(RangePartitioner)
+	if !ok || o.N != p.N || o.Ascending != p.Ascending || len(o.Bounds) != len(p.Bounds) {
+		return false
+	}
+	for i := range p.Bounds {
+		if CompareValues(p.Bounds[i], o.Bounds[i]) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// CustomPartitioner places keys with a function from PartitionerRegistry.
+type CustomPartitioner struct {
+	Name string
+	N    int
+}
+
+func (p CustomPartitioner) NumPartitions() int {
+	return p.N
+}
+
+func (p CustomPartitioner) Partition(key interface{}) int {
+	partition := PartitionerRegistry[p.Name](key, p.N) % p.N
+	if partition < 0 {
+		partition += p.N
+	}
+	return partition
+}
+
+func (p CustomPartitioner) Compatible(other Partitioner) bool {
+	o, ok := other.(CustomPartitioner)
+	return ok && o.Name == p.Name && o.N == p.N
+}
+
+// NewPartitioner returns the hash partitioner for "" or "hash", or the custom
+// partitioner registered under name. Range partitioners depend on the data and
+// are built from a sample of the keys instead.
+func NewPartitioner(name string, numPartitions int) (Partitioner, error) {
+	if name == "" || name == "hash" {
+		return HashPartitioner{N: numPartitions}, nil
+	}
+	if _, exists := PartitionerRegistry[name]; !exists {
+		return nil, fmt.Errorf("partitioner '%s' not found", name)
+	}
+	return CustomPartitioner{Name: name, N: numPartitions}, nil
+}
+
+// PartitionRows groups rows by the partition p assigns to their key.
+func PartitionRows(rows []types.Row, p Partitioner) map[int][]types.Row {
+	partitions := make(map[int][]types.Row)
+	for _, row := range rows {
+		partition := p.Partition(row.Key)
+		partitions[partition] = append(partitions[partition], row)
+	}
+	return partitions
+}
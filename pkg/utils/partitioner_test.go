@@ -0,0 +1,157 @@
+package utils
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"Go-Mini-Spark/pkg/types"
+)
+
+func TestRangePartitionerOrdering(t *testing.T) {
+	keys := rand.New(rand.NewSource(1)).Perm(1000)
+	sample := make([]interface{}, 0, len(keys)/10)
+	for _, k := range keys[:100] {
+		sample = append(sample, k)
+	}
+	rows := make([]types.Row, len(keys))
+	for i, k := range keys {
+		rows[i] = types.Row{Key: k}
+	}
+
+	tests := []struct {
+		name      string
+		n         int
+		ascending bool
+	}{
+		{"ascending", 4, true},
+		{"descending", 4, false},
+		{"single partition", 1, true},
+		{"more partitions than sample", 200, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := RangePartitioner{N: tt.n, Bounds: RangeBounds(sample, tt.n), Ascending: tt.ascending}
+			parts := PartitionRows(rows, p)
+
+			// collecting the partitions in order must visit the keys in order:
+			// every key of a partition sorts before (after, if descending)
+			// every key of the next non-empty one
+			var last interface{}
+			total := 0
+			for i := 0; i < tt.n; i++ {
+				if len(parts[i]) == 0 {
+					continue
+				}
+				lo, hi := parts[i][0].Key, parts[i][0].Key
+				for _, row := range parts[i] {
+					if CompareValues(row.Key, lo) < 0 {
+						lo = row.Key
+					}
+					if CompareValues(row.Key, hi) > 0 {
+						hi = row.Key
+					}
+				}
+				first, end := lo, hi
+				if !tt.ascending {
+					first, end = hi, lo
+				}
+				if last != nil && (CompareValues(first, last) <= 0) == tt.ascending {
+					t.Errorf("partition %d starts at %v, out of order after %v", i, first, last)
+				}
+				last = end
+				total += len(parts[i])
+			}
+			for i := range parts {
+				if i < 0 || i >= tt.n {
+					t.Errorf("partition index %d out of range [0, %d)", i, tt.n)
+				}
+			}
+			if total != len(rows) {
+				t.Errorf("partitions hold %d rows, want %d", total, len(rows))
+			}
+		})
+	}
+}
+
+func TestRangePartitionerMixedKeys(t *testing.T) {
+	// nil sorts first, numbers before strings, like CompareValues
+	p := RangePartitioner{N: 3, Bounds: []interface{}{10, "m"}, Ascending: true}
+	tests := []struct {
+		key  interface{}
+		want int
+	}{
+		{nil, 0},
+		{-5, 0},
+		{10, 0},
+		{10.5, 1},
+		{"a", 1},
+		{"m", 1},
+		{"z", 2},
+	}
+	for _, tt := range tests {
+		if got := p.Partition(tt.key); got != tt.want {
+			t.Errorf("Partition(%#v) = %d, want %d", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestHashPartitioner(t *testing.T) {
+	p := HashPartitioner{N: 5}
+	for _, key := range []interface{}{nil, 0, 1, "a", "long key", 2.5} {
+		got := p.Partition(key)
+		if got < 0 || got >= p.N {
+			t.Errorf("Partition(%#v) = %d, want in [0, %d)", key, got, p.N)
+		}
+		if again := p.Partition(key); again != got {
+			t.Errorf("Partition(%#v) = %d then %d, want the same partition", key, got, again)
+		}
+	}
+}
+
+func TestPartitionerCompatible(t *testing.T) {
+	bounds := []interface{}{1, 5}
+	tests := []struct {
+		name string
+		a, b Partitioner
+		want bool
+	}{
+		{"same hash", HashPartitioner{N: 4}, HashPartitioner{N: 4}, true},
+		{"hash with other N", HashPartitioner{N: 4}, HashPartitioner{N: 8}, false},
+		{"same range", RangePartitioner{N: 3, Bounds: bounds, Ascending: true}, RangePartitioner{N: 3, Bounds: []interface{}{1, 5}, Ascending: true}, true},
+		{"range with other bounds", RangePartitioner{N: 3, Bounds: bounds, Ascending: true}, RangePartitioner{N: 3, Bounds: []interface{}{1, 6}, Ascending: true}, false},
+		{"range with other direction", RangePartitioner{N: 3, Bounds: bounds, Ascending: true}, RangePartitioner{N: 3, Bounds: bounds, Ascending: false}, false},
+		{"hash and range", HashPartitioner{N: 3}, RangePartitioner{N: 3, Bounds: bounds, Ascending: true}, false},
+		{"same custom", CustomPartitioner{Name: "FirstLetter", N: 4}, CustomPartitioner{Name: "FirstLetter", N: 4}, true},
+		{"custom and hash", CustomPartitioner{Name: "FirstLetter", N: 4}, HashPartitioner{N: 4}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.a.Compatible(tt.b); got != tt.want {
+			t.Errorf("%s: Compatible = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNewPartitioner(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Partitioner
+		wantErr bool
+	}{
+		{"", HashPartitioner{N: 4}, false},
+		{"hash", HashPartitioner{N: 4}, false},
+		{"FirstLetter", CustomPartitioner{Name: "FirstLetter", N: 4}, false},
+		{"missing", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := NewPartitioner(tt.name, 4)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NewPartitioner(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("NewPartitioner(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"plugin"
+	"strings"
+
+	"Go-Mini-Spark/pkg/types"
+)
+
+// PluginSymbol is the variable a plugin exports with its functions, keyed by
+// name. Each value must have one of the signatures Register accepts:
+//
+//	var Functions = map[string]interface{}{
+//		"Reverse": func(r types.Row) types.Row { ... },
+//	}
+//
+// Plugins are built with go build -buildmode=plugin against the same module
+// and Go version as the binary that loads them.
+const PluginSymbol = "Functions"
+
+// PluginNamespace returns the plugin namespace of a registered function name,
+// the part before the first ".", or "" for functions built into the binary.
+func PluginNamespace(name string) string {
+	namespace, _, found := strings.Cut(name, ".")
+	if !found {
+		return ""
+	}
+	return namespace
+}
+
+// LoadPlugin opens the plugin at path and registers its functions in
+// FuncRegistry as "<namespace>.<name>", where namespace is the file name
+// without the .so extension. Either every function is registered or none is.
+func LoadPlugin(path string) (types.PluginInfo, error) {
+	namespace := strings.TrimSuffix(filepath.Base(path), ".so")
+	info := types.PluginInfo{Name: namespace, Path: path}
+	if namespace == "" || strings.Contains(namespace, ".") {
+		return info, fmt.Errorf("plugin %s: invalid namespace '%s'", path, namespace)
+	}
+
+	p, err := plugin.Open(path)
+	if err != nil {
+		return info, err
+	}
+	symbol, err := p.Lookup(PluginSymbol)
+	if err != nil {
+		return info, err
+	}
+	functions, ok := symbol.(*map[string]interface{})
+	if !ok {
+		return info, fmt.Errorf("plugin %s: %s is %T, expected map[string]interface{}", path, PluginSymbol, symbol)
+	}
+
+	// check every signature before touching FuncRegistry
+	staged := NewRegistry()
+	for name, fn := range *functions {
+		if err := staged.Register(namespace+"."+name, fn); err != nil {
+			return info, fmt.Errorf("plugin %s: %w", path, err)
+		}
+	}
+	for _, f := range staged.List() {
+		if _, exists := FuncRegistry.Get(f.Name); exists {
+			return info, fmt.Errorf("plugin %s: function '%s' already registered", path, f.Name)
+		}
+	}
+
+	for _, f := range staged.List() {
+		entry, _ := staged.Get(f.Name)
+		if err := FuncRegistry.Register(f.Name, entry.fn); err != nil {
+			return info, fmt.Errorf("plugin %s: %w", path, err)
+		}
+		info.Functions = append(info.Functions, f)
+	}
+	return info, nil
+}
+
+// PluginFiles lists the .so files in dir, in name order.
+func PluginFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		if !entry.IsDir() && filepath.Ext(entry.Name()) == ".so" {
+			paths = append(paths, filepath.Join(dir, entry.Name()))
+		}
+	}
+	return paths, nil
+}
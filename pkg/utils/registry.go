@@ -0,0 +1,236 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"Go-Mini-Spark/pkg/types"
+)
+
+// RegisteredFunc is a registry entry together with the kind inferred from its
+// signature when it was registered.
+type RegisteredFunc struct {
+	types.FuncInfo
+	fn        interface{}
+	checkArgs func(args []interface{}) error
+}
+
+// Registry maps function names to functions whose kind is known, so a wrong
+// op/function pairing is reported as an error instead of a failed assertion.
+type Registry struct {
+	mu    sync.RWMutex
+	funcs map[string]RegisteredFunc
+}
+
+// FuncRegistry holds the functions transformations can reference by name.
+var FuncRegistry = NewRegistry()
+
+func init() {
+	for name, fn := range builtinFuncs {
+		if err := FuncRegistry.Register(name, fn); err != nil {
+			panic(err)
+		}
+	}
+	for name, check := range builtinArgChecks {
+		if err := FuncRegistry.SetArgsCheck(name, check); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func NewRegistry() *Registry {
+	return &Registry{funcs: make(map[string]RegisteredFunc)}
+}
+
+// funcKind infers the kind of fn from its signature. parameterized reports
+// whether it also receives the decoded args (see bindArgs).
+func funcKind(fn interface{}) (kind types.FuncKind, parameterized bool, ok bool) {
+	switch fn.(type) {
+	case func(types.Row) types.Row:
+		return types.MapperKind, false, true
+	case func(types.Row) bool:
+		return types.PredicateKind, false, true
+	case func(types.Row) []types.Row:
+		return types.FlatMapperKind, false, true
+	case func(types.Row, types.Row) types.Row:
+		return types.ReducerKind, false, true
+	case func(types.Row) interface{}:
+		return types.KeyExtractorKind, false, true
+	case func(types.Row, types.Row) int:
+		return types.ComparatorKind, false, true
+	case func([]types.Row) []types.Row:
+		return types.PartitionMapperKind, false, true
+	case func(int, []types.Row) []types.Row:
+		return types.IndexedPartitionMapperKind, false, true
+	case func(types.Row, []interface{}) types.Row:
+		return types.MapperKind, true, true
+	case func(types.Row, []interface{}) bool:
+		return types.PredicateKind, true, true
+	case func(types.Row, []interface{}) []types.Row:
+		return types.FlatMapperKind, true, true
+	case func(types.Row, types.Row, []interface{}) types.Row:
+		return types.ReducerKind, true, true
+	case func(types.Row, []interface{}) interface{}:
+		return types.KeyExtractorKind, true, true
+	}
+	return 0, false, false
+}
+
+// Register adds fn under name. fn must have one of the signatures of
+// types.FuncKind, optionally with the args parameter, or be an AccumulatorFunc
+// that returns such a function.
+func (r *Registry) Register(name string, fn interface{}) error {
+	info := types.FuncInfo{Name: name}
+	impl := fn
+	if factory, ok := fn.(AccumulatorFunc); ok {
+		info.Accumulators = true
+		impl = factory(NewAccumulators())
+	}
+
+	kind, parameterized, ok := funcKind(impl)
+	if !ok {
+		return fmt.Errorf("function '%s' has unsupported signature %T", name, impl)
+	}
+	info.Kind = kind
+	info.Parameterized = parameterized
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.funcs[name]; exists {
+		return fmt.Errorf("function '%s' already registered", name)
+	}
+	r.funcs[name] = RegisteredFunc{FuncInfo: info, fn: fn}
+	return nil
+}
+
+// Get returns the entry registered under name.
+func (r *Registry) Get(name string) (RegisteredFunc, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	f, exists := r.funcs[name]
+	return f, exists
+}
+
+// Check reports an error unless name is registered with the given kind.
+func (r *Registry) Check(name string, kind types.FuncKind) error {
+	f, exists := r.Get(name)
+	if !exists {
+		return fmt.Errorf("function '%s' not found", name)
+	}
+	if f.Kind != kind {
+		return fmt.Errorf("function '%s' is a %s, expected a %s", name, f.Kind, kind)
+	}
+	return nil
+}
+
+// SetArgsCheck makes CheckArgs validate the args of the parameterized function
+// name with check.
+func (r *Registry) SetArgsCheck(name string, check func(args []interface{}) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f, exists := r.funcs[name]
+	if !exists {
+		return fmt.Errorf("function '%s' not found", name)
+	}
+	if !f.Parameterized {
+		return fmt.Errorf("function '%s' does not take arguments", name)
+	}
+	f.checkArgs = check
+	r.funcs[name] = f
+	return nil
+}
+
+// CheckArgs reports an error unless args are valid for name: a function
+// without parameters takes none, and a parameterized one must pass the check
+// set with SetArgsCheck, if any. It lets the driver reject bad args when the
+// transformation is submitted instead of failing on every row.
+func (r *Registry) CheckArgs(name string, args []interface{}) error {
+	f, exists := r.Get(name)
+	if !exists {
+		return fmt.Errorf("function '%s' not found", name)
+	}
+	if !f.Parameterized {
+		if len(args) > 0 {
+			return fmt.Errorf("function '%s' does not take arguments, got %v", name, args)
+		}
+		return nil
+	}
+	if f.checkArgs == nil {
+		return nil
+	}
+	if err := f.checkArgs(args); err != nil {
+		return fmt.Errorf("invalid arguments for '%s': %w", name, err)
+	}
+	return nil
+}
+
+// List describes every registered function, sorted by name.
+func (r *Registry) List() []types.FuncInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	infos := make([]types.FuncInfo, 0, len(r.funcs))
+	for _, f := range r.funcs {
+		infos = append(infos, f.FuncInfo)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// lookup returns the function name as a T. An AccumulatorFunc is built for acc
+// (with a nil acc its updates are discarded) and a parameterized function is
+// bound to args.
+func lookup[T any](r *Registry, name string, kind types.FuncKind, acc *Accumulators, args []interface{}) (T, error) {
+	var zero T
+	if err := r.Check(name, kind); err != nil {
+		return zero, err
+	}
+
+	f, _ := r.Get(name)
+	fn := f.fn
+	if factory, ok := fn.(AccumulatorFunc); ok {
+		if acc == nil {
+			acc = NewAccumulators()
+		}
+		fn = factory(acc)
+	}
+
+	typed, ok := bindArgs(fn, args).(T)
+	if !ok {
+		return zero, fmt.Errorf("function '%s' does not have the signature of a %s", name, kind)
+	}
+	return typed, nil
+}
+
+func (r *Registry) Mapper(name string, acc *Accumulators, args []interface{}) (func(types.Row) types.Row, error) {
+	return lookup[func(types.Row) types.Row](r, name, types.MapperKind, acc, args)
+}
+
+func (r *Registry) Predicate(name string, acc *Accumulators, args []interface{}) (func(types.Row) bool, error) {
+	return lookup[func(types.Row) bool](r, name, types.PredicateKind, acc, args)
+}
+
+func (r *Registry) FlatMapper(name string, acc *Accumulators, args []interface{}) (func(types.Row) []types.Row, error) {
+	return lookup[func(types.Row) []types.Row](r, name, types.FlatMapperKind, acc, args)
+}
+
+func (r *Registry) Reducer(name string, acc *Accumulators, args []interface{}) (func(types.Row, types.Row) types.Row, error) {
+	return lookup[func(types.Row, types.Row) types.Row](r, name, types.ReducerKind, acc, args)
+}
+
+func (r *Registry) KeyExtractor(name string, acc *Accumulators, args []interface{}) (func(types.Row) interface{}, error) {
+	return lookup[func(types.Row) interface{}](r, name, types.KeyExtractorKind, acc, args)
+}
+
+func (r *Registry) Comparator(name string, acc *Accumulators, args []interface{}) (func(types.Row, types.Row) int, error) {
+	return lookup[func(types.Row, types.Row) int](r, name, types.ComparatorKind, acc, args)
+}
+
+func (r *Registry) PartitionMapper(name string, acc *Accumulators, args []interface{}) (func([]types.Row) []types.Row, error) {
+	return lookup[func([]types.Row) []types.Row](r, name, types.PartitionMapperKind, acc, args)
+}
+
+func (r *Registry) IndexedPartitionMapper(name string, acc *Accumulators, args []interface{}) (func(int, []types.Row) []types.Row, error) {
+	return lookup[func(int, []types.Row) []types.Row](r, name, types.IndexedPartitionMapperKind, acc, args)
+}
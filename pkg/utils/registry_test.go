@@ -0,0 +1,128 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"Go-Mini-Spark/pkg/types"
+)
+
+func TestRegistryKinds(t *testing.T) {
+	tests := []struct {
+		fn            interface{}
+		kind          types.FuncKind
+		parameterized bool
+	}{
+		{func(r types.Row) types.Row { return r }, types.MapperKind, false},
+		{func(r types.Row) bool { return true }, types.PredicateKind, false},
+		{func(r types.Row) []types.Row { return nil }, types.FlatMapperKind, false},
+		{func(a, b types.Row) types.Row { return a }, types.ReducerKind, false},
+		{func(r types.Row) interface{} { return r.Key }, types.KeyExtractorKind, false},
+		{func(a, b types.Row) int { return 0 }, types.ComparatorKind, false},
+		{func(rows []types.Row) []types.Row { return rows }, types.PartitionMapperKind, false},
+		{func(i int, rows []types.Row) []types.Row { return rows }, types.IndexedPartitionMapperKind, false},
+		{func(r types.Row, args []interface{}) types.Row { return r }, types.MapperKind, true},
+		{func(r types.Row, args []interface{}) bool { return true }, types.PredicateKind, true},
+		{func(a, b types.Row, args []interface{}) types.Row { return a }, types.ReducerKind, true},
+		{AccumulatorFunc(func(acc *Accumulators) interface{} {
+			return func(r types.Row) bool { return true }
+		}), types.PredicateKind, false},
+	}
+
+	r := NewRegistry()
+	for i, tt := range tests {
+		name := fmt.Sprintf("f%d", i)
+		if err := r.Register(name, tt.fn); err != nil {
+			t.Fatalf("Register(%T): %v", tt.fn, err)
+		}
+		f, _ := r.Get(name)
+		if f.Kind != tt.kind || f.Parameterized != tt.parameterized {
+			t.Errorf("%T registered as %s (parameterized %v), want %s (parameterized %v)",
+				tt.fn, f.Kind, f.Parameterized, tt.kind, tt.parameterized)
+		}
+		if err := r.Check(name, tt.kind); err != nil {
+			t.Errorf("Check(%s, %s): %v", name, tt.kind, err)
+		}
+	}
+}
+
+func TestRegistryErrors(t *testing.T) {
+	r := NewRegistry()
+	if err := r.Register("Upper", func(r types.Row) types.Row { return r }); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"duplicate", r.Register("Upper", func(r types.Row) types.Row { return r }), "already registered"},
+		{"unsupported signature", r.Register("Bad", func(s string) string { return s }), "unsupported signature"},
+		{"missing", r.Check("Nope", types.MapperKind), "not found"},
+		{"wrong kind", r.Check("Upper", types.PredicateKind), "is a"},
+		{"args check without parameters", r.SetArgsCheck("Upper", func([]interface{}) error { return nil }), "does not take arguments"},
+	}
+	for _, tt := range tests {
+		if tt.err == nil || !strings.Contains(tt.err.Error(), tt.want) {
+			t.Errorf("%s: error = %v, want it to contain %q", tt.name, tt.err, tt.want)
+		}
+	}
+
+	if _, err := r.Predicate("Upper", nil, nil); err == nil {
+		t.Error("Predicate of a mapper succeeded, want error")
+	}
+}
+
+func TestRegistryBindsArgs(t *testing.T) {
+	r := NewRegistry()
+	err := r.Register("Suffix", func(row types.Row, args []interface{}) types.Row {
+		return types.Row{Key: row.Key, Value: fmt.Sprintf("%v%v", row.Value, args[0])}
+	})
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	fn, err := r.Mapper("Suffix", nil, []interface{}{"!"})
+	if err != nil {
+		t.Fatalf("Mapper: %v", err)
+	}
+	if got := fn(types.Row{Value: "hi"}).Value; got != "hi!" {
+		t.Errorf("bound mapper returned %v, want hi!", got)
+	}
+}
+
+func TestCheckArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []interface{}
+		wantErr string
+	}{
+		{"ToUpper", nil, ""},
+		{"ToUpper", []interface{}{1}, "does not take arguments"},
+		{"IsLong", nil, ""},
+		{"IsLong", []interface{}{5}, ""},
+		{"IsLong", []interface{}{"five"}, "invalid arguments for 'IsLong'"},
+		{"Contains", []interface{}{"a"}, ""},
+		{"Contains", nil, "expected a substring"},
+		{"MatchesRegex", []interface{}{"^a.*"}, ""},
+		{"MatchesRegex", []interface{}{"("}, "invalid pattern"},
+		{"ColumnEquals", []interface{}{"name", "Apple"}, ""},
+		{"ColumnEquals", []interface{}{"name"}, "expected column and value"},
+		{"Missing", nil, "not found"},
+	}
+
+	for _, tt := range tests {
+		err := FuncRegistry.CheckArgs(tt.name, tt.args)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("CheckArgs(%s, %v): %v", tt.name, tt.args, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("CheckArgs(%s, %v) error = %v, want %q", tt.name, tt.args, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"Go-Mini-Spark/pkg/types"
+)
+
+// partitionRand returns the random source of one partition. Seeding with the
+// partition index keeps partitions independent and every run reproducible.
+func partitionRand(seed int64, partitionIndex int) *rand.Rand {
+	return rand.New(rand.NewSource(seed*1_000_003 + int64(partitionIndex)))
+}
+
+// poisson draws from a Poisson distribution with mean lambda (Knuth's method).
+func poisson(rng *rand.Rand, lambda float64) int {
+	if lambda <= 0 {
+		return 0
+	}
+	limit := math.Exp(-lambda)
+	k, p := 0, 1.0
+	for {
+		p *= rng.Float64()
+		if p <= limit {
+			return k
+		}
+		k++
+	}
+}
+
+// SampleRows keeps each row whose uniform draw falls in [lower, upper). With
+// replacement, each row is instead repeated a Poisson(upper-lower) number of
+// times. Splits that use the same seed and disjoint ranges never share a row.
+func SampleRows(rows []types.Row, partitionIndex int, seed int64, withReplacement bool, lower, upper float64) []types.Row {
+	rng := partitionRand(seed, partitionIndex)
+	result := []types.Row{}
+
+	for _, row := range rows {
+		if withReplacement {
+			for n := poisson(rng, upper-lower); n > 0; n-- {
+				result = append(result, row)
+			}
+			continue
+		}
+		if x := rng.Float64(); x >= lower && x < upper {
+			result = append(result, row)
+		}
+	}
+	return result
+}
+
+// SampleRowsByKey samples each row with the fraction given for its key, keys
+// are looked up by their fmt representation. Rows of keys without a fraction
+// are dropped.
+func SampleRowsByKey(rows []types.Row, partitionIndex int, seed int64, withReplacement bool, fractions map[string]interface{}) []types.Row {
+	rng := partitionRand(seed, partitionIndex)
+	result := []types.Row{}
+
+	for _, row := range rows {
+		fraction, _ := fractions[fmt.Sprintf("%v", row.Key)].(float64)
+		if withReplacement {
+			for n := poisson(rng, fraction); n > 0; n-- {
+				result = append(result, row)
+			}
+			continue
+		}
+		if rng.Float64() < fraction {
+			result = append(result, row)
+		}
+	}
+	return result
+}
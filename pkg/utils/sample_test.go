@@ -0,0 +1,119 @@
+package utils
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"Go-Mini-Spark/pkg/types"
+)
+
+func numberedRows(n int) []types.Row {
+	rows := make([]types.Row, n)
+	for i := range rows {
+		rows[i] = types.Row{Key: i % 3, Value: i}
+	}
+	return rows
+}
+
+func TestSampleRowsRandomSplit(t *testing.T) {
+	rows := numberedRows(10000)
+
+	tests := []struct {
+		name    string
+		weights []float64
+	}{
+		{"halves", []float64{0.5, 0.5}},
+		{"uneven", []float64{0.1, 0.3, 0.6}},
+		{"empty split", []float64{0.4, 0, 0.6}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// RandomSplit samples every split with the same seed and the
+			// consecutive ranges of the normalized weights
+			seen := make(map[int]int)
+			lower := 0.0
+			for i, w := range tt.weights {
+				upper := lower + w
+				if i == len(tt.weights)-1 {
+					upper = 1
+				}
+				split := SampleRows(rows, 0, 42, false, lower, upper)
+
+				want := w * float64(len(rows))
+				if math.Abs(float64(len(split))-want) > 0.05*float64(len(rows)) {
+					t.Errorf("split %d has %d rows, want about %.0f", i, len(split), want)
+				}
+				for _, row := range split {
+					if previous, dup := seen[row.Value.(int)]; dup {
+						t.Fatalf("row %v is in splits %d and %d", row, previous, i)
+					}
+					seen[row.Value.(int)] = i
+				}
+				lower = upper
+			}
+
+			if len(seen) != len(rows) {
+				t.Errorf("splits cover %d rows, want all %d", len(seen), len(rows))
+			}
+		})
+	}
+}
+
+func TestSampleRows(t *testing.T) {
+	rows := numberedRows(10000)
+
+	tests := []struct {
+		name            string
+		withReplacement bool
+		fraction        float64
+	}{
+		{"without replacement", false, 0.2},
+		{"without replacement none", false, 0},
+		{"without replacement all", false, 1},
+		{"with replacement", true, 0.5},
+		{"with replacement oversample", true, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SampleRows(rows, 3, 7, tt.withReplacement, 0, tt.fraction)
+
+			want := tt.fraction * float64(len(rows))
+			if math.Abs(float64(len(got))-want) > 0.05*float64(len(rows)) {
+				t.Errorf("sample has %d rows, want about %.0f", len(got), want)
+			}
+			if again := SampleRows(rows, 3, 7, tt.withReplacement, 0, tt.fraction); !reflect.DeepEqual(got, again) {
+				t.Error("the same seed and partition produced a different sample")
+			}
+		})
+	}
+}
+
+func TestSampleRowsPartitionsDiffer(t *testing.T) {
+	rows := numberedRows(1000)
+	first := SampleRows(rows, 0, 7, false, 0, 0.5)
+	second := SampleRows(rows, 1, 7, false, 0, 0.5)
+	if reflect.DeepEqual(first, second) {
+		t.Error("partitions 0 and 1 produced the same sample, want independent draws")
+	}
+}
+
+func TestSampleRowsByKey(t *testing.T) {
+	rows := numberedRows(30000)
+	fractions := map[string]interface{}{"0": 0.1, "1": 0.5}
+
+	got := SampleRowsByKey(rows, 0, 11, false, fractions)
+
+	counts := make(map[interface{}]int)
+	for _, row := range got {
+		counts[row.Key]++
+	}
+	for key, fraction := range map[int]float64{0: 0.1, 1: 0.5, 2: 0} {
+		want := fraction * 10000
+		if math.Abs(float64(counts[key])-want) > 500 {
+			t.Errorf("key %d has %d rows, want about %.0f", key, counts[key], want)
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package utils
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/bits"
+	"sort"
+
+	"Go-Mini-Spark/pkg/types"
+)
+
+// hash64 hashes s with FNV-1a and mixes the result with the splitmix64
+// finalizer, so that every bit of the hash is usable by the sketches.
+func hash64(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	x := h.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// SketchItem returns the string a sketch counts for a row: the canonical
+// encoding of the whole row, or the named column of a ReadCSV row.
+func SketchItem(row types.Row, field string) string {
+	if field == "" {
+		return CanonicalRow(row)
+	}
+	if m, ok := row.Value.(map[string]interface{}); ok {
+		return fmt.Sprintf("%v", m[field])
+	}
+	return fmt.Sprintf("%v", row.Value)
+}
+
+// HyperLogLog estimates the number of distinct items using 2^P registers,
+// with a relative standard error of about 1.04/sqrt(2^P). Sketches with the
+// same precision can be merged.
+type HyperLogLog struct {
+	P         uint8
+	Registers []uint8
+}
+
+// HLLPrecision returns the precision needed for the given relative standard
+// error, between 4 and 18.
+func HLLPrecision(relativeSD float64) uint8 {
+	if relativeSD <= 0 {
+		return 14
+	}
+	p := math.Ceil(2 * math.Log2(1.04/relativeSD))
+	return uint8(math.Max(4, math.Min(18, p)))
+}
+
+// NewHyperLogLog returns an empty sketch with 2^p registers.
+func NewHyperLogLog(p uint8) HyperLogLog {
+	return HyperLogLog{P: p, Registers: make([]uint8, 1<<p)}
+}
+
+// Add counts item.
+func (h *HyperLogLog) Add(item string) {
+	x := hash64(item)
+	index := x >> (64 - h.P)
+	w := x<<h.P | 1<<(h.P-1)
+	rank := uint8(bits.LeadingZeros64(w)) + 1
+	if rank > h.Registers[index] {
+		h.Registers[index] = rank
+	}
+}
+
+// Merge adds the items counted by other, which must have the same precision.
+func (h *HyperLogLog) Merge(other HyperLogLog) error {
+	if h.P != other.P {
+		return fmt.Errorf("cannot merge HyperLogLog sketches of precision %d and %d", h.P, other.P)
+	}
+	for i, r := range other.Registers {
+		if r > h.Registers[i] {
+			h.Registers[i] = r
+		}
+	}
+	return nil
+}
+
+// Estimate returns the approximate number of distinct items, using Ertl's
+// improved estimator, which has no bias in the range where the classic
+// estimator switches to linear counting.
+func (h HyperLogLog) Estimate() float64 {
+	m := float64(len(h.Registers))
+	q := 64 - int(h.P)
+
+	// histograma de los valores de los registros
+	counts := make([]float64, q+2)
+	for _, r := range h.Registers {
+		counts[r]++
+	}
+
+	z := m * hllTau(1-counts[q+1]/m)
+	for k := q; k >= 1; k-- {
+		z = 0.5 * (z + counts[k])
+	}
+	z += m * hllSigma(counts[0]/m)
+
+	return m * m / (2 * math.Ln2) / z
+}
+
+func hllSigma(x float64) float64 {
+	if x == 1 {
+		return math.Inf(1)
+	}
+	y, z := 1.0, x
+	for {
+		x *= x
+		prev := z
+		z += x * y
+		y += y
+		if z == prev {
+			return z
+		}
+	}
+}
+
+func hllTau(x float64) float64 {
+	if x == 0 || x == 1 {
+		return 0
+	}
+	y, z := 1.0, 1-x
+	for {
+		x = math.Sqrt(x)
+		prev := z
+		y *= 0.5
+		z -= math.Pow(1-x, 2) * y
+		if z == prev {
+			return z / 3
+		}
+	}
+}
+
+// QuantileSketch is a KLL sketch: level h keeps items of weight 2^h, and a
+// full level is sorted and every other item is promoted to the next level.
+// Its rank error shrinks roughly as 1/K. Sketches with the same K can be merged.
+type QuantileSketch struct {
+	K      int
+	Levels [][]float64
+	Count  int64
+	Coin   uint64 // estado del generador que elige qué mitad se promueve
+}
+
+// NewQuantileSketch returns an empty sketch, k controls size and accuracy.
+func NewQuantileSketch(k int) QuantileSketch {
+	return QuantileSketch{K: k, Levels: [][]float64{{}}, Coin: 0x9e3779b97f4a7c15}
+}
+
+// capacity of level h, the top level holds K items and lower levels shrink
+// geometrically.
+func (s *QuantileSketch) capacity(h int) int {
+	depth := len(s.Levels) - 1 - h
+	return max(2, int(math.Ceil(float64(s.K)*math.Pow(2.0/3.0, float64(depth)))))
+}
+
+// flip returns a pseudo random bit (xorshift), deterministic for a given
+// sequence of inserts.
+func (s *QuantileSketch) flip() int {
+	s.Coin ^= s.Coin << 13
+	s.Coin ^= s.Coin >> 7
+	s.Coin ^= s.Coin << 17
+	return int(s.Coin & 1)
+}
+
+// Add includes x in the sketch.
+func (s *QuantileSketch) Add(x float64) {
+	s.Levels[0] = append(s.Levels[0], x)
+	s.Count++
+	s.compress()
+}
+
+// Merge includes the items of other, which must have the same K.
+func (s *QuantileSketch) Merge(other QuantileSketch) error {
+	if s.K != other.K {
+		return fmt.Errorf("cannot merge quantile sketches with K %d and %d", s.K, other.K)
+	}
+	for len(s.Levels) < len(other.Levels) {
+		s.Levels = append(s.Levels, []float64{})
+	}
+	for h, items := range other.Levels {
+		s.Levels[h] = append(s.Levels[h], items...)
+	}
+	s.Count += other.Count
+	s.compress()
+	return nil
+}
+
+func (s *QuantileSketch) compress() {
+	for h := 0; h < len(s.Levels); h++ {
+		if len(s.Levels[h]) < s.capacity(h) {
+			continue
+		}
+		if h == len(s.Levels)-1 {
+			s.Levels = append(s.Levels, []float64{})
+		}
+
+		items := s.Levels[h]
+		sort.Float64s(items)
+
+		// con cantidad impar el último item se queda en este nivel
+		keep := []float64{}
+		if len(items)%2 == 1 {
+			keep = append(keep, items[len(items)-1])
+			items = items[:len(items)-1]
+		}
+		for i := s.flip(); i < len(items); i += 2 {
+			s.Levels[h+1] = append(s.Levels[h+1], items[i])
+		}
+		s.Levels[h] = keep
+	}
+}
+
+// Quantile returns the approximate value at rank q (0 <= q <= 1), or NaN if
+// the sketch is empty.
+func (s QuantileSketch) Quantile(q float64) float64 {
+	type weighted struct {
+		value  float64
+		weight int64
+	}
+	items := []weighted{}
+	total := int64(0)
+	for h, level := range s.Levels {
+		for _, x := range level {
+			items = append(items, weighted{x, 1 << h})
+			total += 1 << h
+		}
+	}
+	if total == 0 {
+		return math.NaN()
+	}
+
+	sort.Slice(items, func(i, j int) bool { return items[i].value < items[j].value })
+	target := q * float64(total)
+	cumulative := int64(0)
+	for _, item := range items {
+		cumulative += item.weight
+		if float64(cumulative) >= target {
+			return item.value
+		}
+	}
+	return items[len(items)-1].value
+}
+
+// ApproxDistinct builds a HyperLogLog over the rows of one partition.
+func ApproxDistinct(rows []types.Row, field string, p uint8) HyperLogLog {
+	h := NewHyperLogLog(p)
+	for _, row := range rows {
+		h.Add(SketchItem(row, field))
+	}
+	return h
+}
+
+// Quantiles builds a QuantileSketch over the numeric field of the rows of one
+// partition. Non numeric values are skipped.
+func Quantiles(rows []types.Row, field string, k int) QuantileSketch {
+	s := NewQuantileSketch(k)
+	for _, row := range rows {
+		if x, ok := NumericField(row, field); ok {
+			s.Add(x)
+		}
+	}
+	return s
+}
@@ -0,0 +1,127 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestHyperLogLogError(t *testing.T) {
+	tests := []struct {
+		p        uint8
+		distinct int
+	}{
+		{p: 10, distinct: 100},
+		{p: 10, distinct: 10000},
+		{p: 12, distinct: 1000},
+		{p: 12, distinct: 100000},
+		{p: 14, distinct: 50000},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("p=%d n=%d", tt.p, tt.distinct), func(t *testing.T) {
+			h := NewHyperLogLog(tt.p)
+			for i := 0; i < tt.distinct; i++ {
+				item := fmt.Sprintf("item-%d", i)
+				// duplicates must not change the estimate
+				h.Add(item)
+				h.Add(item)
+			}
+
+			// 3 standard errors
+			bound := 3 * 1.04 / math.Sqrt(float64(uint(1)<<tt.p))
+			got := h.Estimate()
+			if relErr := math.Abs(got-float64(tt.distinct)) / float64(tt.distinct); relErr > bound {
+				t.Errorf("Estimate = %.0f, want %d within %.1f%% (error %.1f%%)", got, tt.distinct, bound*100, relErr*100)
+			}
+		})
+	}
+}
+
+func TestHyperLogLogMerge(t *testing.T) {
+	// two partitions that share half of their items
+	a, b, all := NewHyperLogLog(12), NewHyperLogLog(12), NewHyperLogLog(12)
+	for i := 0; i < 20000; i++ {
+		item := fmt.Sprintf("item-%d", i)
+		if i < 15000 {
+			a.Add(item)
+		}
+		if i >= 5000 {
+			b.Add(item)
+		}
+		all.Add(item)
+	}
+
+	if err := a.Merge(b); err != nil {
+		t.Fatalf("Merge: %v", err)
+	}
+	if got, want := a.Estimate(), all.Estimate(); got != want {
+		t.Errorf("merged Estimate = %.0f, want %.0f as a single sketch over all items", got, want)
+	}
+
+	if err := a.Merge(NewHyperLogLog(10)); err == nil {
+		t.Error("Merge of different precisions succeeded, want error")
+	}
+}
+
+func TestQuantileSketchError(t *testing.T) {
+	const n = 100000
+	tests := []struct {
+		k          int
+		partitions int
+	}{
+		{k: 100, partitions: 1},
+		{k: 200, partitions: 1},
+		{k: 200, partitions: 8},
+	}
+	quantiles := []float64{0, 0.01, 0.25, 0.5, 0.75, 0.99, 1}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("k=%d partitions=%d", tt.k, tt.partitions), func(t *testing.T) {
+			// the values 0..n-1 in random order, so the rank of x is x/n
+			values := rand.New(rand.NewSource(1)).Perm(n)
+
+			s := NewQuantileSketch(tt.k)
+			for i := 0; i < tt.partitions; i++ {
+				partial := NewQuantileSketch(tt.k)
+				for _, x := range values[i*n/tt.partitions : (i+1)*n/tt.partitions] {
+					partial.Add(float64(x))
+				}
+				if err := s.Merge(partial); err != nil {
+					t.Fatalf("Merge: %v", err)
+				}
+			}
+			if s.Count != n {
+				t.Errorf("Count = %d, want %d", s.Count, n)
+			}
+
+			// KLL rank error is about 1/k, allow 2/k
+			bound := 2 / float64(tt.k)
+			for _, q := range quantiles {
+				rank := s.Quantile(q) / n
+				if math.Abs(rank-q) > bound {
+					t.Errorf("Quantile(%v) has rank %.4f, want within %.4f", q, rank, bound)
+				}
+			}
+		})
+	}
+}
+
+func TestQuantileSketchEdges(t *testing.T) {
+	if got := NewQuantileSketch(100).Quantile(0.5); !math.IsNaN(got) {
+		t.Errorf("Quantile of empty sketch = %v, want NaN", got)
+	}
+
+	s := NewQuantileSketch(100)
+	s.Add(42)
+	for _, q := range []float64{0, 0.5, 1} {
+		if got := s.Quantile(q); got != 42 {
+			t.Errorf("Quantile(%v) of a single item = %v, want 42", q, got)
+		}
+	}
+
+	if err := s.Merge(NewQuantileSketch(50)); err == nil {
+		t.Error("Merge of different K succeeded, want error")
+	}
+}
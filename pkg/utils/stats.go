@@ -0,0 +1,146 @@
+package utils
+
+import (
+	"math"
+	"sort"
+	"strconv"
+
+	"Go-Mini-Spark/pkg/types"
+)
+
+var toFloat = func(row types.Row) (float64, bool) {
+	switch v := row.Value.(type) {
+	case int:
+		return float64(v), true
+	case string:
+		if num, err := strconv.ParseFloat(v, 64); err == nil {
+			return num, true
+		}
+		return 0, false
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
+
+// NumericField returns the row value as a float64, or the value of the named
+// column when the row comes from ReadCSV. Empty field means the whole value.
+func NumericField(row types.Row, field string) (float64, bool) {
+	if field == "" {
+		return toFloat(row)
+	}
+	m, ok := row.Value.(map[string]interface{})
+	if !ok {
+		return 0, false
+	}
+	return toFloat(types.Row{Value: m[field]})
+}
+
+// StatCounter keeps count, sum, mean and sum of squared deviations (Welford)
+// plus min and max of a set of numbers. Counters built on separate partitions can
+// be merged.
+type StatCounter struct {
+	Count   int64
+	Sum     float64
+	Mean    float64
+	M2      float64
+	Min     float64
+	Max     float64
+	Skipped int64 // valores que no se pudieron convertir a número
+}
+
+// NewStatCounter returns an empty counter.
+func NewStatCounter() StatCounter {
+	return StatCounter{Min: math.Inf(1), Max: math.Inf(-1)}
+}
+
+// Add includes x in the counter.
+func (s *StatCounter) Add(x float64) {
+	s.Count++
+	s.Sum += x
+	delta := x - s.Mean
+	s.Mean += delta / float64(s.Count)
+	s.M2 += delta * (x - s.Mean)
+	s.Min = math.Min(s.Min, x)
+	s.Max = math.Max(s.Max, x)
+}
+
+// Merge includes the values counted by other.
+func (s *StatCounter) Merge(other StatCounter) {
+	s.Skipped += other.Skipped
+	if other.Count == 0 {
+		return
+	}
+	if s.Count == 0 {
+		skipped := s.Skipped
+		*s = other
+		s.Skipped = skipped
+		return
+	}
+
+	count := s.Count + other.Count
+	delta := other.Mean - s.Mean
+	s.Mean += delta * float64(other.Count) / float64(count)
+	s.M2 += other.M2 + delta*delta*float64(s.Count)*float64(other.Count)/float64(count)
+	s.Count = count
+	s.Sum += other.Sum
+	s.Min = math.Min(s.Min, other.Min)
+	s.Max = math.Max(s.Max, other.Max)
+}
+
+// Variance is the population variance.
+func (s StatCounter) Variance() float64 {
+	if s.Count == 0 {
+		return math.NaN()
+	}
+	return s.M2 / float64(s.Count)
+}
+
+// Stdev is the population standard deviation.
+func (s StatCounter) Stdev() float64 {
+	return math.Sqrt(s.Variance())
+}
+
+// Stats builds a StatCounter over the numeric field of each row.
+func Stats(rows []types.Row, field string) StatCounter {
+	s := NewStatCounter()
+	for _, row := range rows {
+		x, ok := NumericField(row, field)
+		if !ok {
+			s.Skipped++
+			continue
+		}
+		s.Add(x)
+	}
+	return s
+}
+
+// Histogram counts the numeric field of each row in the buckets delimited by
+// edges, which must be sorted. Every bucket is [edges[i], edges[i+1]) except
+// the last one, which also includes its upper edge. Values outside the edges
+// are ignored.
+func Histogram(rows []types.Row, field string, edges []float64) []int {
+	if len(edges) < 2 {
+		return []int{}
+	}
+	counts := make([]int, len(edges)-1)
+	last := edges[len(edges)-1]
+
+	for _, row := range rows {
+		x, ok := NumericField(row, field)
+		if !ok || x < edges[0] || x > last {
+			continue
+		}
+		if x == last {
+			counts[len(counts)-1]++
+			continue
+		}
+		bucket := sort.SearchFloat64s(edges, x)
+		if edges[bucket] != x {
+			bucket--
+		}
+		counts[bucket]++
+	}
+	return counts
+}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"container/heap"
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
@@ -13,6 +14,7 @@ import (
 	"strings"
 	"hash/fnv"
 	"path/filepath"
+	"reflect"
 )
 
 
@@ -34,7 +36,44 @@ var toInt = func(row types.Row) (int, bool) {
 	}
 }
 
-var FuncRegistry = map[string]interface{}{
+// builtinArgChecks validate the args of the parameterized builtinFuncs, see
+// Registry.CheckArgs
+var builtinArgChecks = map[string]func(args []interface{}) error{
+	"IsLong": func(args []interface{}) error {
+		if len(args) == 0 {
+			return nil
+		}
+		if _, ok := toInt(types.Row{Value: args[0]}); !ok || len(args) > 1 {
+			return fmt.Errorf("expected an optional int length, got %v", args)
+		}
+		return nil
+	},
+	"Contains": func(args []interface{}) error {
+		if _, ok := stringArg(args, 0); !ok || len(args) != 1 {
+			return fmt.Errorf("expected a substring, got %v", args)
+		}
+		return nil
+	},
+	"MatchesRegex": func(args []interface{}) error {
+		pattern, ok := stringArg(args, 0)
+		if !ok || len(args) != 1 {
+			return fmt.Errorf("expected a pattern, got %v", args)
+		}
+		if _, err := compileRegexp(pattern); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		return nil
+	},
+	"ColumnEquals": func(args []interface{}) error {
+		if _, ok := stringArg(args, 0); !ok || len(args) != 2 {
+			return fmt.Errorf("expected column and value, got %v", args)
+		}
+		return nil
+	},
+}
+
+// builtinFuncs are registered in FuncRegistry at startup
+var builtinFuncs = map[string]interface{}{
     "ToUpper": func(r types.Row) types.Row {
 		str, ok := r.Value.(string)
 		if !ok {
@@ -68,15 +107,67 @@ var FuncRegistry = map[string]interface{}{
 		return types.Row{Key: r.Key, Value: count}
 	},
 
-    "IsLong": func(r types.Row) bool {
+    // args: optional minimum length, longFuncValue by default
+    "IsLong": func(r types.Row, args []interface{}) bool {
         str, ok := r.Value.(string)
 		if !ok {
 			log.Printf("IsLong: expected string but got %T\n", r.Value)
 			return false
 		}
-		return len(str) > longFuncValue
+		length := longFuncValue
+		if len(args) > 0 {
+			n, ok := toInt(types.Row{Value: args[0]})
+			if !ok {
+				log.Printf("IsLong: expected int length but got %T\n", args[0])
+				return false
+			}
+			length = n
+		}
+		return len(str) > length
     },
 
+	// args: substring. Keeps rows whose value contains it
+	"Contains": func(r types.Row, args []interface{}) bool {
+		substr, ok := stringArg(args, 0)
+		if !ok {
+			log.Printf("Contains: expected a string argument but got %v\n", args)
+			return false
+		}
+		return strings.Contains(fmt.Sprintf("%v", r.Value), substr)
+	},
+
+	// args: pattern. Keeps rows whose value matches the regular expression
+	"MatchesRegex": func(r types.Row, args []interface{}) bool {
+		pattern, ok := stringArg(args, 0)
+		if !ok {
+			log.Printf("MatchesRegex: expected a string argument but got %v\n", args)
+			return false
+		}
+		re, err := compileRegexp(pattern)
+		if err != nil {
+			log.Printf("MatchesRegex: invalid pattern %q: %v\n", pattern, err)
+			return false
+		}
+		return re.MatchString(fmt.Sprintf("%v", r.Value))
+	},
+
+	// args: column, value. Keeps rows (as built by ReadCSV) whose column equals
+	// value, both compared by their fmt representation
+	"ColumnEquals": func(r types.Row, args []interface{}) bool {
+		column, ok := stringArg(args, 0)
+		if !ok || len(args) < 2 {
+			log.Printf("ColumnEquals: expected column and value arguments but got %v\n", args)
+			return false
+		}
+		fields, ok := r.Value.(map[string]interface{})
+		if !ok {
+			log.Printf("ColumnEquals: expected map but got %T\n", r.Value)
+			return false
+		}
+		value, exists := fields[column]
+		return exists && fmt.Sprintf("%v", value) == fmt.Sprintf("%v", args[1])
+	},
+
     "SplitWords": func(r types.Row) []types.Row {
         str, ok := r.Value.(string)
 		if !ok {
@@ -91,6 +182,100 @@ var FuncRegistry = map[string]interface{}{
 		return rows
     },
 
+	"WordPair": func(r types.Row) types.Row {
+		return types.Row{Key: r.Value, Value: 1}
+	},
+
+	"Sum": func(a types.Row, b types.Row) types.Row {
+		numA, okA := toInt(a)
+		numB, okB := toInt(b)
+		if !okA || !okB {
+			log.Printf("sum: expected int but got %T and %T\n", a.Value, b.Value)
+			return types.Row{Key: a.Key, Value: 0}
+		}
+		return types.Row{Key: a.Key, Value: numA + numB}
+	},
+
+	"ParseNumber": func(r types.Row) interface{} {
+		num, ok := toInt(r)
+		if !ok {
+			return r.Value
+		}
+		return num
+	},
+
+	"Length": func(r types.Row) interface{} {
+		str, ok := r.Value.(string)
+		if !ok {
+			log.Printf("Length: expected string but got %T\n", r.Value)
+			return 0
+		}
+		return len(str)
+	},
+
+	"CompareKey": func(a types.Row, b types.Row) int {
+		return CompareValues(a.Key, b.Key)
+	},
+
+	"CompareValue": CompareByValue,
+
+	"DedupPartition": Distinct,
+
+	"SortPartition": func(rows []types.Row) []types.Row {
+		return SortRows(rows, func(r types.Row) interface{} { return r.Value }, true)
+	},
+
+	"PartitionSize": func(partitionIndex int, rows []types.Row) []types.Row {
+		return []types.Row{{Key: partitionIndex, Value: len(rows)}}
+	},
+
+	"Min": func(a types.Row, b types.Row) types.Row {
+		numA, okA := toInt(a)
+		numB, okB := toInt(b)
+		if !okA || !okB {
+			log.Printf("min: expected int but got %T and %T\n", a.Value, b.Value)
+			return types.Row{Key: nil, Value: 0}
+		}
+		if numA < numB {
+			return a
+		}
+		return b
+	},
+
+	"ToSumCount": func(r types.Row) types.Row {
+		num, ok := toInt(r)
+		if !ok {
+			log.Printf("ToSumCount: expected int but got %T\n", r.Value)
+			return types.Row{Key: r.Key, Value: map[string]interface{}{"sum": 0, "count": 0}}
+		}
+		return types.Row{Key: r.Key, Value: map[string]interface{}{"sum": num, "count": 1}}
+	},
+
+	"AddToSumCount": func(acc types.Row, r types.Row) types.Row {
+		sum, count := sumCount(acc)
+		if num, ok := toInt(r); ok {
+			sum += num
+			count++
+		} else {
+			log.Printf("AddToSumCount: expected int but got %T\n", r.Value)
+		}
+		return types.Row{Key: acc.Key, Value: map[string]interface{}{"sum": sum, "count": count}}
+	},
+
+	"MergeSumCount": func(a types.Row, b types.Row) types.Row {
+		sumA, countA := sumCount(a)
+		sumB, countB := sumCount(b)
+		return types.Row{Key: a.Key, Value: map[string]interface{}{"sum": sumA + sumB, "count": countA + countB}}
+	},
+
+	"Average": func(r types.Row) types.Row {
+		sum, count := sumCount(r)
+		if count == 0 {
+			return types.Row{Key: r.Key, Value: 0.0}
+		}
+		return types.Row{Key: r.Key, Value: float64(sum) / float64(count)}
+	},
+
 	"Max": func(a types.Row, b types.Row) types.Row {
 		numA, okA := toInt(a)
 		numB, okB := toInt(b)
@@ -103,6 +288,83 @@ var FuncRegistry = map[string]interface{}{
 		}
 		return b
 	},
+
+	// keeps rows whose value is not in the "stopwords" broadcast
+	"IsNotStopWord": func(r types.Row) bool {
+		value, ok := BroadcastValue("stopwords")
+		if !ok {
+			return true
+		}
+		word := strings.ToLower(fmt.Sprintf("%v", r.Value))
+		switch words := value.(type) {
+		case []string:
+			for _, stop := range words {
+				if stop == word {
+					return false
+				}
+			}
+		default:
+			log.Printf("IsNotStopWord: expected []string but got %T\n", value)
+		}
+		return true
+	},
+
+	// replaces the value with the entry for the row key in the "lookup" broadcast
+	"LookupKey": func(r types.Row) types.Row {
+		value, ok := BroadcastValue("lookup")
+		if !ok {
+			return r
+		}
+		key := fmt.Sprintf("%v", r.Key)
+		switch lookup := value.(type) {
+		case map[string]interface{}:
+			if v, found := lookup[key]; found {
+				return types.Row{Key: r.Key, Value: v}
+			}
+		case map[string]string:
+			if v, found := lookup[key]; found {
+				return types.Row{Key: r.Key, Value: v}
+			}
+		case map[string]float64:
+			if v, found := lookup[key]; found {
+				return types.Row{Key: r.Key, Value: v}
+			}
+		default:
+			log.Printf("LookupKey: expected map but got %T\n", value)
+		}
+		return r
+	},
+
+	// keeps rows whose value is a map without empty fields (as built by
+	// ReadCSV), counting the others in the "malformed" accumulator and their
+	// keys in the "malformedKeys" set accumulator
+	"IsWellFormed": AccumulatorFunc(func(acc *Accumulators) interface{} {
+		return func(r types.Row) bool {
+			fields, wellFormed := r.Value.(map[string]interface{})
+			for _, v := range fields {
+				if v == nil || v == "" {
+					wellFormed = false
+				}
+			}
+			if !wellFormed {
+				acc.Add("malformed", 1)
+				acc.AddToSet("malformedKeys", r.Key)
+			}
+			return wellFormed
+		}
+	}),
+}
+
+// sumCount reads the {"sum", "count"} combiner built by ToSumCount
+func sumCount(r types.Row) (int, int) {
+	m, ok := r.Value.(map[string]interface{})
+	if !ok {
+		log.Printf("sumCount: expected map but got %T\n", r.Value)
+		return 0, 0
+	}
+	sum, _ := m["sum"].(int)
+	count, _ := m["count"].(int)
+	return sum, count
 }
 
 // Map applies a function to each element in a slice and returns a new slice
@@ -118,7 +380,7 @@ func Map(data []types.Row, fn func(types.Row) types.Row) []types.Row {
 func Filter(data []types.Row, predicate func(types.Row) bool) []types.Row {
 	var result []types.Row
 	for _, item := range data {
-		if predicate(item.Value.(types.Row)) {
+		if predicate(item) {
 			result = append(result, item)
 		}
 	}
@@ -137,38 +399,246 @@ func FlatMap(rows []types.Row, fn func(types.Row) []types.Row) []types.Row {
     return result
 }
 
+// MapValues applies fn to each row and keeps the original key, so rows do not
+// move between partitions
+func MapValues(rows []types.Row, fn func(types.Row) types.Row) []types.Row {
+	result := make([]types.Row, len(rows))
+	for i, row := range rows {
+		result[i] = types.Row{Key: row.Key, Value: fn(row).Value}
+	}
+	return result
+}
+
+// FlatMapValues expands each row with fn and gives every output row the key of
+// the row it came from
+func FlatMapValues(rows []types.Row, fn func(types.Row) []types.Row) []types.Row {
+	result := []types.Row{}
+	for _, row := range rows {
+		for _, out := range fn(row) {
+			result = append(result, types.Row{Key: row.Key, Value: out.Value})
+		}
+	}
+	return result
+}
+
+// Keys returns one row per input row with the key as its value
+func Keys(rows []types.Row) []types.Row {
+	result := make([]types.Row, len(rows))
+	for i, row := range rows {
+		result[i] = types.Row{Key: nil, Value: row.Key}
+	}
+	return result
+}
+
+// Values returns one row per input row with only its value
+func Values(rows []types.Row) []types.Row {
+	result := make([]types.Row, len(rows))
+	for i, row := range rows {
+		result[i] = types.Row{Key: nil, Value: row.Value}
+	}
+	return result
+}
+
+// KeyBy sets the key of each row to keyFn(row), keeping its value
+func KeyBy(rows []types.Row, keyFn func(types.Row) interface{}) []types.Row {
+	result := make([]types.Row, len(rows))
+	for i, row := range rows {
+		result[i] = types.Row{Key: keyFn(row), Value: row.Value}
+	}
+	return result
+}
+
 
-func Reduce(data []types.Row, fn func(a types.Row, b types.Row) types.Row) types.Row {
+// Reduce combines all rows with fn. The second result is false when data is
+// empty, since there is no row to start from.
+func Reduce(data []types.Row, fn func(a types.Row, b types.Row) types.Row) (types.Row, bool) {
     if len(data) == 0 {
-        return types.Row{}   
+        return types.Row{}, false
     }
     acc := data[0]
     for i := 1; i < len(data); i++ {
         acc = fn(acc, data[i])
     }
+    return acc, true
+}
+
+// Fold combines all rows with fn starting from zero, so an empty slice
+// returns zero.
+func Fold(data []types.Row, zero types.Row, fn func(a types.Row, b types.Row) types.Row) types.Row {
+    acc := zero
+    for _, row := range data {
+        acc = fn(acc, row)
+    }
     return acc
 }
 
-// Shuffle redistribuye filas entre particiones basado en el hash de la key.
-func Shuffle(rows []types.Row, numPartitions int) map[int][]types.Row {
-    partitions := make(map[int][]types.Row)
+// ReduceByKey combines the rows of each key with fn, keeping the keys in the
+// order they first appear. Keys are compared by their fmt representation, the
+// same one Shuffle hashes.
+func ReduceByKey(data []types.Row, fn func(a types.Row, b types.Row) types.Row) []types.Row {
+	index := make(map[string]int)
+	result := []types.Row{}
+
+	for _, row := range data {
+		keyStr := fmt.Sprintf("%v", row.Key)
+		i, seen := index[keyStr]
+		if !seen {
+			index[keyStr] = len(result)
+			result = append(result, row)
+			continue
+		}
+		acc := fn(result[i], row)
+		acc.Key = row.Key
+		result[i] = acc
+	}
 
-    for _, row := range rows {
-        if row.Key == nil {
-            continue
-        }
+	return result
+}
 
-        // Convertir key a string para hashing
-        keyStr := fmt.Sprintf("%v", row.Key)
+// CombineByKey builds one combiner per key: the first row of each key goes
+// through createCombiner and the following ones are added with mergeValue.
+func CombineByKey(data []types.Row, createCombiner func(types.Row) types.Row, mergeValue func(a types.Row, b types.Row) types.Row) []types.Row {
+	index := make(map[string]int)
+	result := []types.Row{}
+
+	for _, row := range data {
+		keyStr := fmt.Sprintf("%v", row.Key)
+		i, seen := index[keyStr]
+		if !seen {
+			index[keyStr] = len(result)
+			acc := createCombiner(row)
+			acc.Key = row.Key
+			result = append(result, acc)
+			continue
+		}
+		acc := mergeValue(result[i], row)
+		acc.Key = row.Key
+		result[i] = acc
+	}
 
-        partition := HashPartition(keyStr, numPartitions)
+	return result
+}
 
-        partitions[partition] = append(partitions[partition], row)
-    }
+// GroupByKey gathers the values of each key into a Row{Key, []interface{}},
+// keeping the keys in the order they first appear.
+func GroupByKey(data []types.Row) []types.Row {
+	index := make(map[string]int)
+	result := []types.Row{}
+
+	for _, row := range data {
+		keyStr := fmt.Sprintf("%v", row.Key)
+		i, seen := index[keyStr]
+		if !seen {
+			i = len(result)
+			index[keyStr] = i
+			result = append(result, types.Row{Key: row.Key, Value: []interface{}{}})
+		}
+		result[i].Value = append(result[i].Value.([]interface{}), row.Value)
+	}
+
+	return result
+}
+
+// CoGroup gathers, for every key present on either side, the values of the
+// left and the right rows as Row{Key, []interface{}{leftValues, rightValues}}.
+func CoGroup(leftRows []types.Row, rightRows []types.Row) []types.Row {
+	index := make(map[string]int)
+	keys := []interface{}{}
+	groups := [][2][]interface{}{}
+
+	add := func(row types.Row, side int) {
+		keyStr := fmt.Sprintf("%v", row.Key)
+		i, seen := index[keyStr]
+		if !seen {
+			i = len(keys)
+			index[keyStr] = i
+			keys = append(keys, row.Key)
+			groups = append(groups, [2][]interface{}{{}, {}})
+		}
+		groups[i][side] = append(groups[i][side], row.Value)
+	}
 
-    return partitions
+	for _, row := range leftRows {
+		add(row, 0)
+	}
+	for _, row := range rightRows {
+		add(row, 1)
+	}
+
+	result := make([]types.Row, len(keys))
+	for i, key := range keys {
+		result[i] = types.Row{
+			Key:   key,
+			Value: []interface{}{groups[i][0], groups[i][1]},
+		}
+	}
+	return result
+}
+
+// CanonicalRow encodes a whole row, key and value with their types, so that
+// equal rows always produce the same string. fmt prints maps with sorted keys.
+func CanonicalRow(row types.Row) string {
+	return fmt.Sprintf("%T:%v|%T:%v", row.Key, row.Key, row.Value, row.Value)
 }
 
+// ShuffleRows redistributes rows by the hash of their canonical encoding, so
+// that equal rows end up in the same partition.
+func ShuffleRows(rows []types.Row, numPartitions int) map[int][]types.Row {
+	partitions := make(map[int][]types.Row)
+	for _, row := range rows {
+		partition := HashPartition(CanonicalRow(row), numPartitions)
+		partitions[partition] = append(partitions[partition], row)
+	}
+	return partitions
+}
+
+// Distinct removes duplicated rows, keeping the first occurrence of each.
+func Distinct(rows []types.Row) []types.Row {
+	seen := make(map[string]bool)
+	result := []types.Row{}
+	for _, row := range rows {
+		id := CanonicalRow(row)
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		result = append(result, row)
+	}
+	return result
+}
+
+// Intersection returns the distinct rows present on both sides.
+func Intersection(leftRows []types.Row, rightRows []types.Row) []types.Row {
+	inRight := make(map[string]bool)
+	for _, row := range rightRows {
+		inRight[CanonicalRow(row)] = true
+	}
+
+	result := []types.Row{}
+	for _, row := range Distinct(leftRows) {
+		if inRight[CanonicalRow(row)] {
+			result = append(result, row)
+		}
+	}
+	return result
+}
+
+// Subtract returns the rows of the left side that are not present on the
+// right side. Duplicates on the left side are kept.
+func Subtract(leftRows []types.Row, rightRows []types.Row) []types.Row {
+	inRight := make(map[string]bool)
+	for _, row := range rightRows {
+		inRight[CanonicalRow(row)] = true
+	}
+
+	result := []types.Row{}
+	for _, row := range leftRows {
+		if !inRight[CanonicalRow(row)] {
+			result = append(result, row)
+		}
+	}
+	return result
+}
 
 func HashPartition(key string, numPartitions int) int {
     h := fnv.New32a()
@@ -176,43 +646,233 @@ func HashPartition(key string, numPartitions int) int {
     return int(h.Sum32()) % numPartitions
 }
 
-func Join(leftRows []types.Row, rightRows []types.Row) []types.Row {
+// KeyOf is the default sort key extractor, it returns the key of the row.
+func KeyOf(r types.Row) interface{} {
+	return r.Key
+}
+
+// CompareValues orders two values, returning -1, 0 or 1. nil sorts first, then
+// numbers (compared numerically), then strings, then any other value by its fmt
+// representation.
+func CompareValues(a, b interface{}) int {
+	rankA, rankB := valueRank(a), valueRank(b)
+	if rankA != rankB {
+		if rankA < rankB {
+			return -1
+		}
+		return 1
+	}
+
+	switch rankA {
+	case 0:
+		return 0
+	case 1:
+		numA, numB := toFloat64(a), toFloat64(b)
+		switch {
+		case numA < numB:
+			return -1
+		case numA > numB:
+			return 1
+		}
+		return 0
+	case 2:
+		return strings.Compare(a.(string), b.(string))
+	default:
+		return strings.Compare(fmt.Sprintf("%v", a), fmt.Sprintf("%v", b))
+	}
+}
+
+func valueRank(v interface{}) int {
+	switch v.(type) {
+	case nil:
+		return 0
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return 1
+	case string:
+		return 2
+	default:
+		return 3
+	}
+}
+
+func toFloat64(v interface{}) float64 {
+	switch n := v.(type) {
+	case int:
+		return float64(n)
+	case int8:
+		return float64(n)
+	case int16:
+		return float64(n)
+	case int32:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case uint:
+		return float64(n)
+	case uint8:
+		return float64(n)
+	case uint16:
+		return float64(n)
+	case uint32:
+		return float64(n)
+	case uint64:
+		return float64(n)
+	case float32:
+		return float64(n)
+	case float64:
+		return n
+	}
+	return 0
+}
+
+// CompareByValue is the default comparator of Top, it orders rows by Value.
+func CompareByValue(a types.Row, b types.Row) int {
+	return CompareValues(a.Value, b.Value)
+}
+
+// rowHeap is a min-heap of rows under cmp, used to keep the n largest rows.
+type rowHeap struct {
+	rows []types.Row
+	cmp  func(types.Row, types.Row) int
+}
+
+func (h *rowHeap) Len() int           { return len(h.rows) }
+func (h *rowHeap) Less(i, j int) bool { return h.cmp(h.rows[i], h.rows[j]) < 0 }
+func (h *rowHeap) Swap(i, j int)      { h.rows[i], h.rows[j] = h.rows[j], h.rows[i] }
+func (h *rowHeap) Push(x interface{}) { h.rows = append(h.rows, x.(types.Row)) }
+func (h *rowHeap) Pop() interface{} {
+	last := h.rows[len(h.rows)-1]
+	h.rows = h.rows[:len(h.rows)-1]
+	return last
+}
+
+// TopN returns the n largest rows under cmp, largest first.
+func TopN(data []types.Row, n int, cmp func(types.Row, types.Row) int) []types.Row {
+	if n <= 0 {
+		return []types.Row{}
+	}
+
+	h := &rowHeap{cmp: cmp}
+	for _, row := range data {
+		if h.Len() < n {
+			heap.Push(h, row)
+		} else if cmp(row, h.rows[0]) > 0 {
+			h.rows[0] = row
+			heap.Fix(h, 0)
+		}
+	}
+
+	top := make([]types.Row, h.Len())
+	for i := len(top) - 1; i >= 0; i-- {
+		top[i] = heap.Pop(h).(types.Row)
+	}
+	return top
+}
+
+// SortRows sorts the rows by the value keyFn extracts from each of them. The
+// sort is stable, so rows with equal keys keep their relative order.
+func SortRows(data []types.Row, keyFn func(types.Row) interface{}, ascending bool) []types.Row {
+	keys := make([]interface{}, len(data))
+	for i, row := range data {
+		keys[i] = keyFn(row)
+	}
+
+	idx := make([]int, len(data))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(i, j int) bool {
+		cmp := CompareValues(keys[idx[i]], keys[idx[j]])
+		if ascending {
+			return cmp < 0
+		}
+		return cmp > 0
+	})
+
+	sorted := make([]types.Row, len(data))
+	for i, j := range idx {
+		sorted[i] = data[j]
+	}
+	return sorted
+}
+
+// RangeBounds picks numPartitions-1 ascending boundaries from a sample of keys,
+// so that each range receives roughly the same share of the sample.
+func RangeBounds(sample []interface{}, numPartitions int) []interface{} {
+	if len(sample) == 0 || numPartitions <= 1 {
+		return []interface{}{}
+	}
+
+	sorted := make([]interface{}, len(sample))
+	copy(sorted, sample)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return CompareValues(sorted[i], sorted[j]) < 0
+	})
+
+	bounds := make([]interface{}, 0, numPartitions-1)
+	for i := 1; i < numPartitions; i++ {
+		bounds = append(bounds, sorted[i*len(sorted)/numPartitions])
+	}
+	return bounds
+}
+
+// RangePartition returns the index of the range the key belongs to: the first
+// boundary greater than or equal to the key, or len(bounds) for the last range.
+func RangePartition(key interface{}, bounds []interface{}) int {
+	return sort.Search(len(bounds), func(i int) bool {
+		return CompareValues(key, bounds[i]) <= 0
+	})
+}
+
+// Join combina las filas de ambos lados con la misma key según joinType.
+// Los values que no son map[string]interface{} se tratan como una columna
+// "value", y las columnas presentes en ambos lados se resuelven con collision.
+// Las keys se comparan con su tipo (1 no coincide con "1") y una key nil no
+// coincide con ninguna, como NULL en SQL: esas filas solo aparecen sin match en
+// los outer y anti joins.
+func Join(leftRows []types.Row, rightRows []types.Row, joinType types.JoinType, collision types.CollisionPolicy) ([]types.Row, error) {
     // 1. Construimos un índice por clave para el lado derecho
-    rightIndex := make(map[interface{}][]types.Row)
-    for _, r := range rightRows {
-        rightIndex[r.Key] = append(rightIndex[r.Key], r)
-    }
+    rightIndex := newKeyIndex(rightRows)
+    rightMatched := make([]bool, len(rightRows))
 
     var result []types.Row
 
     // 2. Recorremos el lado izquierdo y buscamos coincidencias
     for _, left := range leftRows {
-        matches := rightIndex[left.Key]
-        if len(matches) == 0 {
-            continue // no hay match
-        }
+        matches := rightIndex.lookup(left.Key)
 
-        leftMap, ok := left.Value.(map[string]interface{})
-        if !ok {
+        switch joinType {
+        case types.LeftSemiJoin:
+            if len(matches) > 0 {
+                result = append(result, left)
+            }
+            continue
+        case types.LeftAntiJoin:
+            if len(matches) == 0 {
+                result = append(result, left)
+            }
             continue
         }
 
-        for _, right := range matches {
-            rightMap, ok := right.Value.(map[string]interface{})
-            if !ok {
-                continue
+        if len(matches) == 0 {
+            // sin match, solo se conserva en left y full outer join
+            if joinType == types.LeftOuterJoin || joinType == types.FullOuterJoin {
+                merged, err := mergeColumns(columns(left.Value), nil, collision)
+                if err != nil {
+                    return nil, err
+                }
+                result = append(result, types.Row{Key: left.Key, Value: merged})
             }
+            continue
+        }
 
-            // 3. Crear value combinado (shallow merge)
-            merged := make(map[string]interface{})
+        for _, i := range matches {
+            rightMatched[i] = true
 
-            // copiar left
-            for k, v := range leftMap {
-                merged[k] = v
-            }
-            // copiar right (si hay colisiones, right sobrescribe)
-            for k, v := range rightMap {
-                merged[k] = v
+            // 3. Crear value combinado (shallow merge)
+            merged, err := mergeColumns(columns(left.Value), columns(rightRows[i].Value), collision)
+            if err != nil {
+                return nil, err
             }
 
             // 4. Añadir resultado
@@ -223,7 +883,101 @@ func Join(leftRows []types.Row, rightRows []types.Row) []types.Row {
         }
     }
 
-    return result
+    // 5. Filas del lado derecho sin match para right y full outer join
+    if joinType == types.RightOuterJoin || joinType == types.FullOuterJoin {
+        for i, right := range rightRows {
+            if rightMatched[i] {
+                continue
+            }
+            merged, err := mergeColumns(nil, columns(right.Value), collision)
+            if err != nil {
+                return nil, err
+            }
+            result = append(result, types.Row{Key: right.Key, Value: merged})
+        }
+    }
+
+    return result, nil
+}
+
+// keyIndex finds the rows with a given key. Keys are bucketed by their fmt
+// representation and compared with keysEqual within each bucket, so keys of
+// different types that print the same do not match.
+type keyIndex struct {
+	rows    []types.Row
+	buckets map[string][]int
+}
+
+// newKeyIndex indexes rows by key, rows with a nil key are left out.
+func newKeyIndex(rows []types.Row) keyIndex {
+	index := keyIndex{rows: rows, buckets: make(map[string][]int)}
+	for i, row := range rows {
+		if row.Key == nil {
+			continue
+		}
+		keyStr := fmt.Sprintf("%v", row.Key)
+		index.buckets[keyStr] = append(index.buckets[keyStr], i)
+	}
+	return index
+}
+
+// lookup returns the positions of the rows whose key equals key.
+func (index keyIndex) lookup(key interface{}) []int {
+	if key == nil {
+		return nil
+	}
+	var matches []int
+	for _, i := range index.buckets[fmt.Sprintf("%v", key)] {
+		if keysEqual(index.rows[i].Key, key) {
+			matches = append(matches, i)
+		}
+	}
+	return matches
+}
+
+// keysEqual compares two join keys by type and value. nil never equals
+// anything, not even another nil.
+func keysEqual(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// columns returns the value of a row as a column map. Values that are not a
+// map[string]interface{} become a single "value" column.
+func columns(value interface{}) map[string]interface{} {
+    if m, ok := value.(map[string]interface{}); ok {
+        return m
+    }
+    return map[string]interface{}{"value": value}
+}
+
+// mergeColumns copies the columns of both sides into a new map. A column present
+// on both sides is renamed to left_<col> and right_<col>, or makes the merge fail,
+// depending on collision.
+func mergeColumns(leftMap, rightMap map[string]interface{}, collision types.CollisionPolicy) (map[string]interface{}, error) {
+    merged := make(map[string]interface{}, len(leftMap)+len(rightMap))
+
+    for k, v := range leftMap {
+        if _, dup := rightMap[k]; dup {
+            if collision == types.FailOnCollision {
+                return nil, fmt.Errorf("column '%s' is present on both sides of the join", k)
+            }
+            merged["left_"+k] = v
+            continue
+        }
+        merged[k] = v
+    }
+    for k, v := range rightMap {
+        if _, dup := leftMap[k]; dup {
+            merged["right_"+k] = v
+            continue
+        }
+        merged[k] = v
+    }
+
+    return merged, nil
 }
 
 
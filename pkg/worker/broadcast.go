@@ -0,0 +1,36 @@
+package worker
+
+import (
+	"Go-Mini-Spark/pkg/types"
+	"Go-Mini-Spark/pkg/utils"
+	"fmt"
+	"log"
+	"net/rpc"
+)
+
+// fetchBroadcast pide al driver el valor difundido con el ID dado, utils lo
+// llama la primera vez que una task del worker lo necesita
+func (w *Worker) fetchBroadcast(id string) (interface{}, error) {
+	client, err := rpc.Dial("tcp", w.DriverAddress)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to driver: %v", err)
+	}
+	defer client.Close()
+
+	var reply types.BroadcastVar
+	if err := client.Call("Driver.GetBroadcast", id, &reply); err != nil {
+		return nil, fmt.Errorf("error fetching broadcast '%s': %v", id, err)
+	}
+
+	log.Printf("Worker %d cached broadcast %s\n", w.ID, id)
+	return reply.Value, nil
+}
+
+// DropBroadcast RPC method - borra de la caché el valor difundido con el ID
+// dado, el driver lo llama cuando lo libera
+func (w *Worker) DropBroadcast(id string, reply *bool) error {
+	utils.DropBroadcast(id)
+	log.Printf("Worker %d dropped broadcast %s\n", w.ID, id)
+	*reply = true
+	return nil
+}
@@ -0,0 +1,65 @@
+package worker
+
+import (
+	"fmt"
+	"log"
+
+	"Go-Mini-Spark/pkg/expr"
+	"Go-Mini-Spark/pkg/types"
+	"Go-Mini-Spark/pkg/utils"
+)
+
+// executeExpr aplica una transformación definida por t.Expr en lugar de una
+// función registrada. Un error al evaluar cualquier fila hace fallar la task,
+// y el error indica la fila para que el cliente lo vea en la acción.
+func executeExpr(w *Worker, t types.Transformation, data []types.Row, acc *utils.Accumulators) ([]types.Row, error) {
+	e, err := expr.Parse(t.Expr)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Worker %d executing expression %q of type %d\n", w.ID, t.Expr, t.Type)
+	result := make([]types.Row, 0, len(data))
+	switch t.Type {
+	case types.MapOp, types.MapValuesOp:
+		for _, row := range data {
+			v, err := e.Eval(row)
+			if err != nil {
+				return nil, rowError(row, err)
+			}
+			result = append(result, types.Row{Key: row.Key, Value: v})
+		}
+
+	case types.FilterOp:
+		for _, row := range data {
+			keep, err := e.EvalBool(row)
+			if err != nil {
+				return nil, rowError(row, err)
+			}
+			if keep {
+				result = append(result, row)
+			}
+		}
+		if name, ok := t.Options["droppedAccumulator"].(string); ok {
+			acc.Add(name, float64(len(data)-len(result)))
+		}
+
+	case types.KeyByOp:
+		for _, row := range data {
+			k, err := e.Eval(row)
+			if err != nil {
+				return nil, rowError(row, err)
+			}
+			result = append(result, types.Row{Key: k, Value: row.Value})
+		}
+
+	default:
+		return nil, fmt.Errorf("transformation type %d does not support expressions", t.Type)
+	}
+	return result, nil
+}
+
+// rowError agrega a err la fila con la que falló la expresión
+func rowError(row types.Row, err error) error {
+	return fmt.Errorf("%w (row %v)", err, row)
+}
@@ -0,0 +1,183 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"Go-Mini-Spark/pkg/types"
+)
+
+// pipeStderrLimit es la cantidad de bytes finales de stderr que se incluyen en
+// el error de un Pipe que falla
+const pipeStderrLimit = 4096
+
+// pipeWaitDelay es cuánto se espera, tras matar el proceso, a que se cierren
+// stdout y stderr (pueden seguir abiertos en procesos hijos del ejecutable)
+const pipeWaitDelay = time.Second
+
+// pipeRow es el formato JSON de una fila en la entrada y salida de Pipe
+type pipeRow struct {
+	Key   interface{} `json:"key"`
+	Value interface{} `json:"value"`
+}
+
+// pipe ejecuta el comando de t con las filas de la partición como líneas JSON
+// en stdin y retorna las filas que escribe en stdout. Si el proceso termina con
+// error, no termina a tiempo o escribe algo que no es una fila, la task falla
+// con el final de su stderr.
+func (w *Worker) pipe(t types.Transformation, data []types.Row) ([]types.Row, error) {
+	command, _ := t.Options["command"].(string)
+	args, _ := t.Options["args"].([]string)
+	timeout, _ := t.Options["timeout"].(int)
+
+	if w.PipeDir == "" {
+		return nil, fmt.Errorf("worker %d has no pipe directory, cannot run %q (start it with -pipe-dir)", w.ID, command)
+	}
+	if command == "" || command != filepath.Base(command) {
+		return nil, fmt.Errorf("invalid pipe command %q", command)
+	}
+
+	// las filas se codifican antes de iniciar el proceso, un valor que no se
+	// puede representar en JSON no llega a ejecutarlo
+	var input bytes.Buffer
+	encoder := json.NewEncoder(&input)
+	for _, row := range data {
+		if err := encoder.Encode(pipeRow{Key: row.Key, Value: row.Value}); err != nil {
+			return nil, fmt.Errorf("pipe %s: cannot encode row %v: %w", command, row, err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, filepath.Join(w.PipeDir, command), args...)
+	cmd.WaitDelay = pipeWaitDelay
+	stderr := &tailBuffer{limit: pipeStderrLimit}
+	cmd.Stderr = stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("pipe %s: %w", command, err)
+	}
+
+	// stdin se escribe en otra goroutine para que el proceso pueda escribir en
+	// stdout antes de leer toda su entrada. Si el proceso no lee todo, el error
+	// de escritura se ignora: lo que importa es cómo termina.
+	go func() {
+		io.Copy(stdin, &input)
+		stdin.Close()
+	}()
+
+	// la salida se lee en otra goroutine para no seguir bloqueados después del
+	// timeout si un proceso hijo mantiene stdout abierto
+	type pipeOutput struct {
+		rows []types.Row
+		err  error
+	}
+	output := make(chan pipeOutput, 1)
+	go func() {
+		rows, err := readPipeRows(stdout)
+		output <- pipeOutput{rows, err}
+	}()
+
+	var rows []types.Row
+	var readErr error
+	select {
+	case out := <-output:
+		rows, readErr = out.rows, out.err
+		if readErr != nil {
+			cancel()
+		}
+	case <-ctx.Done():
+	}
+	waitErr := cmd.Wait()
+
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		err = fmt.Errorf("pipe %s timed out after %ds", command, timeout)
+	case readErr != nil:
+		err = fmt.Errorf("pipe %s: %w", command, readErr)
+	case waitErr != nil:
+		err = fmt.Errorf("pipe %s failed: %w", command, waitErr)
+	default:
+		return rows, nil
+	}
+	if tail := strings.TrimSpace(stderr.String()); tail != "" {
+		err = fmt.Errorf("%w\nstderr:\n%s", err, tail)
+	}
+	return nil, err
+}
+
+// readPipeRows lee filas JSON de r hasta EOF
+func readPipeRows(r io.Reader) ([]types.Row, error) {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	decoder.DisallowUnknownFields()
+
+	rows := []types.Row{}
+	for {
+		var row pipeRow
+		err := decoder.Decode(&row)
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("output row %d is not a {\"key\", \"value\"} object: %w", len(rows)+1, err)
+		}
+		rows = append(rows, types.Row{Key: fromJSON(row.Key), Value: fromJSON(row.Value)})
+	}
+}
+
+// fromJSON convierte los números decodificados a int si son enteros y a
+// float64 si no
+func fromJSON(v interface{}) interface{} {
+	switch x := v.(type) {
+	case json.Number:
+		if i, err := x.Int64(); err == nil {
+			return int(i)
+		}
+		f, _ := x.Float64()
+		return f
+	case map[string]interface{}:
+		for k, item := range x {
+			x[k] = fromJSON(item)
+		}
+	case []interface{}:
+		for i, item := range x {
+			x[i] = fromJSON(item)
+		}
+	}
+	return v
+}
+
+// tailBuffer guarda solo los últimos limit bytes escritos
+type tailBuffer struct {
+	limit int
+	buf   []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > b.limit {
+		b.buf = b.buf[len(b.buf)-b.limit:]
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	return string(b.buf)
+}
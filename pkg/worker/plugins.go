@@ -0,0 +1,28 @@
+package worker
+
+import (
+	"log"
+
+	"Go-Mini-Spark/pkg/utils"
+)
+
+// LoadPlugins carga los plugins .so de dir en utils.FuncRegistry, antes de
+// registrarse con el driver. Un plugin que no carga se reporta y se omite; solo
+// es un error no poder leer dir.
+func (w *Worker) LoadPlugins(dir string) error {
+	paths, err := utils.PluginFiles(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		info, err := utils.LoadPlugin(path)
+		if err != nil {
+			log.Printf("Worker %d: skipping plugin %s: %v\n", w.ID, path, err)
+			continue
+		}
+		log.Printf("Worker %d loaded plugin %s with %d functions\n", w.ID, info.Name, len(info.Functions))
+		w.Plugins = append(w.Plugins, info)
+	}
+	return nil
+}
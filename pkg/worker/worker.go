@@ -15,6 +15,15 @@ import (
 func init() {
 	gob.Register(types.Row{})
 	gob.Register(map[string]any{})
+	gob.Register([]interface{}{})
+	gob.Register(utils.StatCounter{})
+	gob.Register(utils.HyperLogLog{})
+	gob.Register(utils.QuantileSketch{})
+	gob.Register([]types.Row{})
+	gob.Register([]string{})
+	gob.Register([]float64{})
+	gob.Register(map[string]string{})
+	gob.Register(map[string]float64{})
 }
 
 const heartBeatInterval = 2
@@ -28,6 +37,8 @@ type Worker struct {
 	DriverAddress string
 	LastHeartbeat time.Time
 	ActiveTasks   int
+	Plugins       []types.PluginInfo // se reportan al driver al registrarse y en cada heartbeat
+	PipeDir       string             // directorio de los ejecutables que puede usar Pipe
 }
 
 func NewWorker(driverAddress, address string, maxTasks int) *Worker {
@@ -45,31 +56,222 @@ func NewWorker(driverAddress, address string, maxTasks int) *Worker {
 	}
 }
 
-func ExecuteTransformation(w *Worker, t types.Transformation, data []types.Row) ([]types.Row, error) {
-	_, exists := utils.FuncRegistry[t.FuncName]
-	if !exists {
-		return nil, fmt.Errorf("transformation function '%s' not found", t.FuncName)
+func ExecuteTransformation(w *Worker, t types.Transformation, partitionIndex int, data []types.Row, acc *utils.Accumulators) ([]types.Row, error) {
+	if t.Expr != "" {
+		return executeExpr(w, t, data, acc)
+	}
+
+	// las funciones se obtienen con su clase (ver types.FuncKind), una función
+	// que no corresponde a la transformación es un error de la task
+	args, err := utils.DecodeArgs(t.Args)
+	if err != nil {
+		return nil, err
 	}
 
 	log.Printf("Worker %d executing transformation %s of type %d\n", w.ID, t.FuncName, t.Type)
 	switch t.Type {
 	case types.MapOp:
-		fn := utils.FuncRegistry[t.FuncName].(func(types.Row) types.Row)
+		fn, err := utils.FuncRegistry.Mapper(t.FuncName, acc, args)
+		if err != nil {
+			return nil, err
+		}
 		data = utils.Map(data, fn)
 
 	case types.FilterOp:
-		fn := utils.FuncRegistry[t.FuncName].(func(types.Row) bool)
+		fn, err := utils.FuncRegistry.Predicate(t.FuncName, acc, args)
+		if err != nil {
+			return nil, err
+		}
+		before := len(data)
 		data = utils.Filter(data, fn)
+		if name, ok := t.Options["droppedAccumulator"].(string); ok {
+			acc.Add(name, float64(before-len(data)))
+		}
 
 	case types.FlatMapOp:
-		fn := utils.FuncRegistry[t.FuncName].(func(types.Row) []types.Row)
+		fn, err := utils.FuncRegistry.FlatMapper(t.FuncName, acc, args)
+		if err != nil {
+			return nil, err
+		}
 		data = utils.FlatMap(data, fn)
 
+	case types.MapValuesOp:
+		fn, err := utils.FuncRegistry.Mapper(t.FuncName, acc, args)
+		if err != nil {
+			return nil, err
+		}
+		data = utils.MapValues(data, fn)
+
+	case types.FlatMapValuesOp:
+		fn, err := utils.FuncRegistry.FlatMapper(t.FuncName, acc, args)
+		if err != nil {
+			return nil, err
+		}
+		data = utils.FlatMapValues(data, fn)
+
+	case types.KeysOp:
+		data = utils.Keys(data)
+
+	case types.ValuesOp:
+		data = utils.Values(data)
+
+	case types.KeyByOp:
+		fn, err := utils.FuncRegistry.KeyExtractor(t.FuncName, acc, args)
+		if err != nil {
+			return nil, err
+		}
+		data = utils.KeyBy(data, fn)
+
+	case types.PipeOp:
+		piped, err := w.pipe(t, data)
+		if err != nil {
+			// el error llega hasta la acción del cliente, que no sabe en qué
+			// worker ni con qué partición se ejecutó el comando
+			return nil, fmt.Errorf("worker %d, partition %d: %w", w.ID, partitionIndex, err)
+		}
+		data = piped
+
+	case types.BroadcastJoinOp:
+		id, _ := t.Options["broadcastID"].(string)
+		value, err := utils.GetBroadcast(id)
+		if err != nil {
+			return nil, err
+		}
+		small, ok := value.([]types.Row)
+		if !ok {
+			return nil, fmt.Errorf("broadcast '%s' does not hold rows", id)
+		}
+		broadcastLeft, _ := t.Options["broadcastLeft"].(bool)
+		joinType, _ := t.Options["joinType"].(int)
+		collision, _ := t.Options["collision"].(int)
+		if broadcastLeft {
+			data, err = utils.Join(small, data, types.JoinType(joinType), types.CollisionPolicy(collision))
+		} else {
+			data, err = utils.Join(data, small, types.JoinType(joinType), types.CollisionPolicy(collision))
+		}
+		if err != nil {
+			return nil, err
+		}
+
 	case types.ReduceOp: 
-		fn := utils.FuncRegistry[t.FuncName].(func(types.Row, types.Row) types.Row)
-		result := utils.Reduce(data, fn)
+		fn, err := utils.FuncRegistry.Reducer(t.FuncName, acc, args)
+		if err != nil {
+			return nil, err
+		}
+		result, ok := utils.Reduce(data, fn)
+		if !ok {
+			// partición vacía, no aporta un resultado parcial
+			data = []types.Row{}
+			break
+		}
 		data = []types.Row{result}
 
+	case types.FoldOp:
+		fn, err := utils.FuncRegistry.Reducer(t.FuncName, acc, args)
+		if err != nil {
+			return nil, err
+		}
+		zero, _ := t.Options["zero"].(types.Row)
+		data = []types.Row{utils.Fold(data, zero, fn)}
+
+	case types.CombineByKeyOp:
+		mergeValue, err := utils.FuncRegistry.Reducer(t.FuncName, acc, args)
+		if err != nil {
+			return nil, err
+		}
+		name, _ := t.Options["createCombiner"].(string)
+		create, err := utils.FuncRegistry.Mapper(name, acc, nil)
+		if err != nil {
+			return nil, err
+		}
+		data = utils.CombineByKey(data, create, mergeValue)
+
+	case types.ReduceByKeyOp:
+		fn, err := utils.FuncRegistry.Reducer(t.FuncName, acc, args)
+		if err != nil {
+			return nil, err
+		}
+		data = utils.ReduceByKey(data, fn)
+
+	case types.GroupByKeyOp:
+		data = utils.GroupByKey(data)
+
+	case types.SortOp:
+		keyFn := utils.KeyOf
+		if t.FuncName != "" {
+			if keyFn, err = utils.FuncRegistry.KeyExtractor(t.FuncName, acc, args); err != nil {
+				return nil, err
+			}
+		}
+		ascending, _ := t.Options["ascending"].(bool)
+		data = utils.SortRows(data, keyFn, ascending)
+
+	case types.MapPartitionsOp:
+		fn, err := utils.FuncRegistry.PartitionMapper(t.FuncName, acc, args)
+		if err != nil {
+			return nil, err
+		}
+		data = fn(data)
+
+	case types.MapPartitionsWithIndexOp:
+		fn, err := utils.FuncRegistry.IndexedPartitionMapper(t.FuncName, acc, args)
+		if err != nil {
+			return nil, err
+		}
+		data = fn(partitionIndex, data)
+
+	case types.DistinctOp:
+		data = utils.Distinct(data)
+
+	case types.SampleOp:
+		seed, _ := t.Options["seed"].(int64)
+		withReplacement, _ := t.Options["withReplacement"].(bool)
+		if fractions, ok := t.Options["fractions"].(map[string]interface{}); ok {
+			data = utils.SampleRowsByKey(data, partitionIndex, seed, withReplacement, fractions)
+			break
+		}
+		lower, _ := t.Options["lower"].(float64)
+		upper, _ := t.Options["upper"].(float64)
+		data = utils.SampleRows(data, partitionIndex, seed, withReplacement, lower, upper)
+
+	case types.StatsOp:
+		field, _ := t.Options["field"].(string)
+		data = []types.Row{{Key: nil, Value: utils.Stats(data, field)}}
+
+	case types.HistogramOp:
+		field, _ := t.Options["field"].(string)
+		edges, _ := t.Options["edges"].([]float64)
+		data = []types.Row{{Key: nil, Value: utils.Histogram(data, field, edges)}}
+
+	case types.ApproxDistinctOp:
+		field, _ := t.Options["field"].(string)
+		precision, _ := t.Options["precision"].(uint8)
+		data = []types.Row{{Key: nil, Value: utils.ApproxDistinct(data, field, precision)}}
+
+	case types.QuantileSketchOp:
+		field, _ := t.Options["field"].(string)
+		k, _ := t.Options["k"].(int)
+		data = []types.Row{{Key: nil, Value: utils.Quantiles(data, field, k)}}
+
+	case types.CountOp:
+		data = []types.Row{{Key: nil, Value: len(data)}}
+
+	case types.TakeOp:
+		n, _ := t.Options["n"].(int)
+		if n < len(data) {
+			data = data[:n]
+		}
+
+	case types.TopOp:
+		cmp := utils.CompareByValue
+		if t.FuncName != "" {
+			if cmp, err = utils.FuncRegistry.Comparator(t.FuncName, acc, args); err != nil {
+				return nil, err
+			}
+		}
+		n, _ := t.Options["n"].(int)
+		data = utils.TopN(data, n, cmp)
+
 	default:
 		return nil, fmt.Errorf("unsupported transformation type %d", t.Type)
 	}
@@ -85,21 +287,52 @@ func (w *Worker) ExecuteTask(task types.Task, reply *types.TaskReply) error {
 		w.ActiveTasks--
 	}()
 
+	accumulators := make(map[int]*utils.Accumulators)
+	data, err := w.runTask(task, accumulators)
+	if err != nil {
+		log.Printf("Worker %d: Error during transformation: %v\n", w.ID, err)
+		return fmt.Errorf("transformation error in task %d: %w", task.ID, err)
+	}
+
+	reply.Data = data
+	reply.Accumulators = make(map[int]types.AccumulatorUpdates)
+	for rddID, acc := range accumulators {
+		reply.Accumulators[rddID] = acc.Updates()
+	}
+	// log.Printf("completed task %d with %s results\n", task.ID, data)
+	return nil
+}
+
+// runTask aplica las transformaciones de task a sus filas. Si la task reúne
+// otras (task.Inputs), primero ejecuta cada una y concatena sus resultados.
+func (w *Worker) runTask(task types.Task, accumulators map[int]*utils.Accumulators) ([]types.Row, error) {
 	data := task.Data
+	if len(task.Inputs) > 0 {
+		data = []types.Row{}
+		for _, input := range task.Inputs {
+			rows, err := w.runTask(input, accumulators)
+			if err != nil {
+				return nil, err
+			}
+			data = append(data, rows...)
+		}
+	}
 
 	// Apply transformations
 	for _, t := range task.Transformations {
-		transformedData, err := ExecuteTransformation(w, t, data)
+		acc, exists := accumulators[t.RDDID]
+		if !exists {
+			acc = utils.NewAccumulators()
+			accumulators[t.RDDID] = acc
+		}
+
+		transformedData, err := ExecuteTransformation(w, t, task.PartitionIndex, data, acc)
 		if err != nil {
-			log.Printf("Worker %d: Error during transformation: %v\n", w.ID, err)
-			return fmt.Errorf("transformation error in task %d: %w", task.ID, err)
+			return nil, err
 		}
 		data = transformedData
 	}
-
-	reply.Data = data
-	// log.Printf("completed task %d with %s results\n", task.ID, data)
-	return nil
+	return data, nil
 }
 
 // GetStatus RPC method
@@ -126,13 +359,52 @@ func (w *Worker) ExecuteJoin(task types.TaskJoin, reply *types.TaskReply) error
 	leftData := task.LeftRows
 	rightData := task.RightRows
 
-	joinedData := utils.Join(leftData, rightData)
+	joinedData, err := utils.Join(leftData, rightData, task.JoinType, task.Collision)
+	if err != nil {
+		return fmt.Errorf("join task %d: %w", task.ID, err)
+	}
 
 	reply.Data = joinedData
 	// log.Printf("completed task %d with %s results\n", task.ID, data)
 	return nil
 }
 
+// ExecuteCoGroup RPC method - agrupa por key las filas de ambos lados de una partición
+func (w *Worker) ExecuteCoGroup(task types.TaskJoin, reply *types.TaskReply) error {
+	log.Printf("Worker %d executing cogroup task %d\n", w.ID, task.ID)
+	w.ActiveTasks++
+	defer func() {
+		w.ActiveTasks--
+	}()
+
+	reply.Data = utils.CoGroup(task.LeftRows, task.RightRows)
+	return nil
+}
+
+// ExecuteIntersection RPC method - filas distintas presentes en ambos lados de una partición
+func (w *Worker) ExecuteIntersection(task types.TaskJoin, reply *types.TaskReply) error {
+	log.Printf("Worker %d executing intersection task %d\n", w.ID, task.ID)
+	w.ActiveTasks++
+	defer func() {
+		w.ActiveTasks--
+	}()
+
+	reply.Data = utils.Intersection(task.LeftRows, task.RightRows)
+	return nil
+}
+
+// ExecuteSubtract RPC method - filas del lado izquierdo que no están en el derecho
+func (w *Worker) ExecuteSubtract(task types.TaskJoin, reply *types.TaskReply) error {
+	log.Printf("Worker %d executing subtract task %d\n", w.ID, task.ID)
+	w.ActiveTasks++
+	defer func() {
+		w.ActiveTasks--
+	}()
+
+	reply.Data = utils.Subtract(task.LeftRows, task.RightRows)
+	return nil
+}
+
 // SendHeartbeat envía un heartbeat al driver
 func (w *Worker) SendHeartbeat(driverAddress string) error {
 	client, err := rpc.Dial("tcp", driverAddress)
@@ -142,13 +414,14 @@ func (w *Worker) SendHeartbeat(driverAddress string) error {
 	defer client.Close()
 
 	w.LastHeartbeat = time.Now()
-	
+
 	heartbeat := types.Heartbeat{
 		ID:            w.ID,
 		Status:        w.Status,
 		ActiveTasks:   w.ActiveTasks,
 		Endpoint:      w.Endpoint,
 		LastHeartbeat: w.LastHeartbeat,
+		Plugins:       w.Plugins,
 	}
 
 	var reply bool
@@ -198,6 +471,7 @@ func (w *Worker) Start(driverAddress string) {
 	client.Close()
 
 	w.StartHeartbeatLoop(heartBeatInterval * time.Second)
+	utils.SetBroadcastFetcher(w.fetchBroadcast)
 
 	// Register RPC methods
 	rpc.Register(w)
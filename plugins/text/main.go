@@ -0,0 +1,44 @@
+// Plugin de ejemplo: sus funciones quedan registradas como "text.Reverse",
+// "text.IsPalindrome" y "text.Truncate" en los workers que lo cargan.
+//
+//	go build -buildmode=plugin -o plugins/text.so ./plugins/text
+//	worker -plugins plugins
+package main
+
+import (
+	"Go-Mini-Spark/pkg/types"
+	"fmt"
+)
+
+// Functions es el símbolo que busca utils.LoadPlugin
+var Functions = map[string]interface{}{
+	"Reverse": func(r types.Row) types.Row {
+		return types.Row{Key: r.Key, Value: reverse(fmt.Sprintf("%v", r.Value))}
+	},
+	"IsPalindrome": func(r types.Row) bool {
+		str := fmt.Sprintf("%v", r.Value)
+		return str == reverse(str)
+	},
+	// recibe la longitud máxima como argumento
+	"Truncate": func(r types.Row, args []interface{}) types.Row {
+		runes := []rune(fmt.Sprintf("%v", r.Value))
+		if len(args) == 0 {
+			return r
+		}
+		if n, ok := args[0].(int); ok && n >= 0 && len(runes) > n {
+			runes = runes[:n]
+		}
+		return types.Row{Key: r.Key, Value: string(runes)}
+	},
+}
+
+func reverse(s string) string {
+	runes := []rune(s)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes)
+}
+
+// main no se usa, go build ./... requiere que exista
+func main() {}
@@ -11,6 +11,8 @@ func main() {
 	driverAddress := flag.String("driver", "localhost:9000", "Driver address (host:port)")
 	workerAddress := flag.String("address", "localhost:9100", "Worker address (host:port)")
 	maxTasks := flag.Int("max-tasks", 10, "Maximum number of tasks to handle")
+	pluginDir := flag.String("plugins", "", "Directory of function plugins (.so) to load at startup")
+	pipeDir := flag.String("pipe-dir", "", "Directory of the executables Pipe transformations may run")
 
 	flag.Parse()
 
@@ -31,5 +33,11 @@ func main() {
 	log.Printf("Starting worker with driver=%s, address=%s, max-tasks=%d\n", *driverAddress, *workerAddress, *maxTasks)
 
 	worker := worker.NewWorker(*driverAddress, *workerAddress, *maxTasks)
+	worker.PipeDir = *pipeDir
+	if *pluginDir != "" {
+		if err := worker.LoadPlugins(*pluginDir); err != nil {
+			log.Fatalf("Error loading plugins from %s: %v", *pluginDir, err)
+		}
+	}
 	worker.Start(*driverAddress)
 }